@@ -29,6 +29,8 @@ type HttpParms struct {
 	ProxyPwd        string      //代理IP密码
 	TimeOut         int         //超时时间，单位：秒，默认30秒，如果提供大于0的数值，则修改操作超时时间
 	AutoFormatEnter bool        //是否将提交的数据内容的换行强制转为\r\n格式，当提交有换行数据有问题时，将此项设为true
+
+	Jar http.CookieJar //Cookie容器，可空，设置后请求自动携带容器中的Cookie，响应及重定向中的Cookie自动存入容器，可用chrome.CookiesToJar与浏览器互通
 }
 
 /**
@@ -47,6 +49,9 @@ func HttpSend(hp *HttpParms) (resStr string, resByte []byte, cookies string, err
 	if hp.TimeOut > 0 {
 		client.Timeout = time.Duration(hp.TimeOut) * time.Second
 	}
+	if hp.Jar != nil {
+		client.Jar = hp.Jar
+	}
 	//if hp.TimeOut == 0 {
 	//	hp.TimeOut = 30
 	//}
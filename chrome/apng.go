@@ -0,0 +1,181 @@
+package chrome
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+)
+
+//APNG文件中acTL块数据的位置：文件签名8字节 + IHDR块25字节 + acTL块长度和类型8字节
+const apngFramesOffset = 8 + 25 + 8
+
+//每帧默认显示时间，用于最后一帧，单位毫秒
+const apngDefaultDelay = 100
+
+//将帧保存为APNG动画，画布大小取首帧尺寸，每帧显示时间按相邻帧的截取时间计算
+type apngWriter struct {
+	f       *os.File
+	buf     *bufio.Writer
+	width   int     //画布宽度
+	height  int     //画布高度
+	frames  uint32  //已写入的帧数
+	seq     uint32  //fcTL、fdAT块共用的序号
+	pending []byte  //等待下一帧到达以确定显示时间的帧，已压缩
+	pendTs  float64 //pending帧的截取时间
+	err     error   //写入过程中的首个错误
+}
+
+func newApngWriter(path string) (*apngWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &apngWriter{f: f, buf: bufio.NewWriter(f)}, nil
+}
+
+func (w *apngWriter) write(data []byte, timestamp float64) error {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if w.width == 0 {
+		w.width, w.height = img.Bounds().Dx(), img.Bounds().Dy()
+		w.writeHeader()
+	}
+	frame := w.compress(img)
+	if w.pending != nil {
+		w.writeFrame(w.pending, int(math.Round((timestamp-w.pendTs)*1000)))
+	}
+	w.pending, w.pendTs = frame, timestamp
+	return w.err
+}
+
+func (w *apngWriter) close() error {
+	if w.width == 0 {
+		//没有任何帧，不保留空文件
+		w.f.Close()
+		return os.Remove(w.f.Name())
+	}
+	w.writeFrame(w.pending, apngDefaultDelay)
+	w.writeChunk("IEND", nil)
+	if w.err == nil {
+		w.err = w.buf.Flush()
+	}
+	if w.err == nil {
+		//写入总帧数，帧数在开始时未知，先写0再回填
+		w.err = w.patchFrames()
+	}
+	if err := w.f.Close(); w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+/**
+写入文件签名、IHDR块和acTL块，固定使用8位RGBA格式
+*/
+func (w *apngWriter) writeHeader() {
+	w.buf.Write([]byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'})
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(w.width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(w.height))
+	ihdr[8] = 8 //位深
+	ihdr[9] = 6 //颜色类型：RGBA
+	w.writeChunk("IHDR", ihdr)
+	//帧数和循环次数，0表示无限循环
+	w.writeChunk("acTL", make([]byte, 8))
+}
+
+/**
+将图片绘制到画布大小后按行压缩为IDAT数据，尺寸与画布不同的帧会被裁剪或补透明
+*/
+func (w *apngWriter) compress(img image.Image) []byte {
+	canvas := image.NewNRGBA(image.Rect(0, 0, w.width, w.height))
+	draw.Draw(canvas, canvas.Bounds(), img, img.Bounds().Min, draw.Src)
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	for y := 0; y < w.height; y++ {
+		//每行前加过滤类型0
+		zw.Write([]byte{0})
+		zw.Write(canvas.Pix[y*canvas.Stride : y*canvas.Stride+w.width*4])
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+/**
+写入一帧，首帧使用IDAT块，之后的帧使用fdAT块
+传参：
+	data：压缩后的帧数据
+	delay：显示时间，单位毫秒
+*/
+func (w *apngWriter) writeFrame(data []byte, delay int) {
+	if delay <= 0 {
+		delay = 1
+	} else if delay > 65535 {
+		delay = 65535
+	}
+	fctl := make([]byte, 26)
+	binary.BigEndian.PutUint32(fctl[0:], w.seq)
+	binary.BigEndian.PutUint32(fctl[4:], uint32(w.width))
+	binary.BigEndian.PutUint32(fctl[8:], uint32(w.height))
+	binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+	binary.BigEndian.PutUint16(fctl[22:], 1000)
+	w.seq++
+	w.writeChunk("fcTL", fctl)
+	if w.frames == 0 {
+		w.writeChunk("IDAT", data)
+	} else {
+		fdat := make([]byte, 4+len(data))
+		binary.BigEndian.PutUint32(fdat, w.seq)
+		copy(fdat[4:], data)
+		w.seq++
+		w.writeChunk("fdAT", fdat)
+	}
+	w.frames++
+}
+
+/**
+写入一个PNG块：长度、类型、数据、CRC校验
+*/
+func (w *apngWriter) writeChunk(typ string, data []byte) {
+	if w.err != nil {
+		return
+	}
+	head := make([]byte, 8)
+	binary.BigEndian.PutUint32(head, uint32(len(data)))
+	copy(head[4:], typ)
+	crc := crc32.NewIEEE()
+	crc.Write(head[4:])
+	crc.Write(data)
+	tail := make([]byte, 4)
+	binary.BigEndian.PutUint32(tail, crc.Sum32())
+	w.buf.Write(head)
+	w.buf.Write(data)
+	_, w.err = w.buf.Write(tail)
+}
+
+/**
+回填acTL块中的帧数并重新计算CRC
+*/
+func (w *apngWriter) patchFrames() error {
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl, w.frames)
+	crc := crc32.NewIEEE()
+	crc.Write([]byte("acTL"))
+	crc.Write(actl)
+	tail := make([]byte, 4)
+	binary.BigEndian.PutUint32(tail, crc.Sum32())
+	if _, err := w.f.WriteAt(actl, apngFramesOffset); err != nil {
+		return err
+	}
+	_, err := w.f.WriteAt(tail, apngFramesOffset+8)
+	return err
+}
@@ -0,0 +1,90 @@
+package chrome
+
+import (
+	"b/big"
+	"errors"
+)
+
+/**
+创建独立的浏览器上下文，一个浏览器进程中可同时存在多个互相隔离的上下文，比Hide启动多个浏览器更省资源
+开启AutoRelaunch时，浏览器重启后会按相同选项重建上下文，上下文中的标签在新上下文中重新打开，原上下文的Cookie等数据不会保留
+传参：
+	opts：上下文选项，可为每个上下文指定不同的代理，传ContextOptions{}表示使用浏览器的代理
+返回：
+	浏览器上下文，失败返回error错误信息
+例：
+	ctx, err := browser.NewContext(chrome.ContextOptions{ProxyServer: "socks5://127.0.0.1:1080"})
+	defer ctx.Close()
+	tag, err := ctx.NewTag("https://www.baidu.com")
+*/
+func (p *Browser) NewContext(opts ContextOptions) (*BrowserContext, error) {
+	id, err := p.createContext(opts)
+	if err != nil {
+		return nil, err
+	}
+	//已设置下载目录时新上下文同样生效，否则上下文中的下载不会推送事件，WaitDownload等不到结果
+	p.connLock.Lock()
+	dir := p.downloadDir
+	p.connLock.Unlock()
+	if dir != "" {
+		if err = p.downloadBehavior(dir, id); err != nil {
+			p.Call("Target.disposeBrowserContext", map[string]interface{}{"browserContextId": id})
+			return nil, err
+		}
+	}
+	ctx := &BrowserContext{Id: id, browser: p, opts: opts}
+	p.contexts.Store(ctx, struct{}{})
+	return ctx, nil
+}
+
+/**
+按选项创建浏览器上下文
+返回：
+	新上下文的ID
+*/
+func (p *Browser) createContext(opts ContextOptions) (string, error) {
+	parm := make(map[string]interface{})
+	if opts.ProxyServer != "" {
+		parm["proxyServer"] = opts.ProxyServer
+	}
+	if opts.ProxyBypassList != "" {
+		parm["proxyBypassList"] = opts.ProxyBypassList
+	}
+	res, err := p.Call("Target.createBrowserContext", parm)
+	if err != nil {
+		return "", err
+	}
+	id := big.StrGetSub(res, "\"browserContextId\":\"", "\"")
+	if id == "" {
+		return "", errors.New("创建浏览器上下文失败：" + res)
+	}
+	return id, nil
+}
+
+/**
+在上下文中创建新标签并附加
+传参：
+	url：新标签网址，可空，默认为about:blank
+返回：
+	*Tag标签对象，失败则返回error错误信息
+*/
+func (p *BrowserContext) NewTag(url string) (*Tag, error) {
+	targetId, err := p.browser.createTarget(url, p.Id)
+	if err != nil {
+		return nil, err
+	}
+	return p.browser.AttachTag(targetId)
+}
+
+/**
+关闭上下文，上下文中的全部标签会一并关闭，Cookie等数据随之销毁
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *BrowserContext) Close() error {
+	_, err := p.browser.Call("Target.disposeBrowserContext", map[string]interface{}{"browserContextId": p.Id})
+	if err == nil {
+		p.browser.contexts.Delete(p)
+	}
+	return err
+}
@@ -0,0 +1,241 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/bitly/go-simplejson"
+	"github.com/gorilla/websocket"
+	"sync"
+)
+
+//ws连接，负责收发消息，Browser和通过Browser附加的Tag共用同一个连接，按sessionId区分目标
+type conn struct {
+	ws        *websocket.Conn
+	lock      sync.Mutex          //互斥锁
+	writeLock sync.Mutex          //ws写消息互斥锁，websocket不支持并发写
+	taskId    int                 //任务ID，自增，同一连接内所有会话共用
+	taskRsp   map[int]chan string //等待响应的任务，key是任务id，value是接收响应结果的通道
+	sessions  map[string]*session //会话集合，key是sessionId，连接所属的目标自身sessionId为空字符串
+	closed    bool                //连接是否已断开
+}
+
+//会话，flatten模式下一个连接可同时操作多个目标，每个目标对应一个会话
+type session struct {
+	id      string                                                        //会话ID，空字符串表示连接所属的目标自身
+	conn    *conn                                                         //所属连接
+	handler func(method string, jsonobj *simplejson.Json, message []byte) //事件回调，在消息监听协程中同步执行
+	done    chan struct{}                                                 //会话结束通知，会话分离或连接断开时关闭
+}
+
+/**
+建立ws连接并开始监听消息
+传参：
+	wsUrl：ws连接地址
+返回：
+	连接对象，失败返回error错误信息
+*/
+func dialConn(wsUrl string) (*conn, error) {
+	ws, _, err := websocket.DefaultDialer.Dial(wsUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{
+		ws:       ws,
+		taskRsp:  make(map[int]chan string),
+		sessions: make(map[string]*session),
+	}
+	go c.onListenerMsg()
+	return c, nil
+}
+
+/**
+在连接上登记会话，此后该sessionId的事件会交给handler处理
+传参：
+	id：会话ID，空字符串表示连接所属的目标自身
+	handler：事件回调
+返回：
+	会话对象，连接已断开时返回ErrDisconnected
+*/
+func (c *conn) newSession(id string, handler func(method string, jsonobj *simplejson.Json, message []byte)) (*session, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return nil, ErrDisconnected
+	}
+	s := &session{
+		id:      id,
+		conn:    c,
+		handler: handler,
+		done:    make(chan struct{}),
+	}
+	c.sessions[id] = s
+	return s, nil
+}
+
+/**
+判断会话是否已登记
+*/
+func (c *conn) hasSession(id string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.sessions[id]
+	return ok
+}
+
+/**
+移除会话并通知会话结束
+*/
+func (c *conn) removeSession(id string) {
+	c.lock.Lock()
+	s, ok := c.sessions[id]
+	delete(c.sessions, id)
+	c.lock.Unlock()
+	if ok {
+		close(s.done)
+	}
+}
+
+/**
+关闭连接，监听协程退出后会通知全部会话结束
+*/
+func (c *conn) close() {
+	c.writeLock.Lock()
+	c.ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	c.writeLock.Unlock()
+	c.ws.Close()
+}
+
+/**
+监听Chrome返回的消息，响应结果交给等待中的调用，事件按sessionId交给对应会话，
+退出时唤醒全部等待响应的调用并结束全部会话
+*/
+func (c *conn) onListenerMsg() {
+	defer func() {
+		//连接已彻底中断
+		c.lock.Lock()
+		c.closed = true
+		sessions := c.sessions
+		c.sessions = make(map[string]*session)
+		c.taskRsp = nil
+		c.lock.Unlock()
+		for _, s := range sessions {
+			close(s.done)
+		}
+	}()
+	//等待回复消息
+	for {
+		_, message, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		if len(message) == 0 {
+			continue
+		}
+		jsonobj, err := simplejson.NewJson(message)
+		if err != nil {
+			continue
+		}
+		_, flag := jsonobj.CheckGet("id")
+		if flag {
+			//如果id字段存在，说明是普通操作的响应结果
+			id, err := jsonobj.Get("id").Int()
+			if err == nil && id > 0 {
+				c.lock.Lock()
+				rsp, ok := c.taskRsp[id]
+				delete(c.taskRsp, id)
+				c.lock.Unlock()
+				if ok {
+					rsp <- string(message)
+				}
+			}
+			continue
+		}
+		//如果id字段不存在，说明是事件的响应结果
+		method, _ := jsonobj.Get("method").String()
+		sessionId, _ := jsonobj.Get("sessionId").String()
+		c.lock.Lock()
+		s := c.sessions[sessionId]
+		c.lock.Unlock()
+		if s != nil && s.handler != nil {
+			s.handler(method, jsonobj, message)
+		}
+	}
+}
+
+/**
+会话是否已结束
+*/
+func (s *session) isClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+/**
+在会话上调用浏览器方法
+传参：
+	ctx：上下文，用于控制超时和取消
+	method：方法名
+	params：参数map类型，可用map传递多个参数
+返回
+	调用结果，超时、取消或连接断开时error为*CallError，浏览器返回错误响应时error为*CDPError
+*/
+func (s *session) call(ctx context.Context, method string, params map[string]interface{}) (string, error) {
+	c := s.conn
+	c.lock.Lock()
+	if c.closed || s.isClosed() {
+		c.lock.Unlock()
+		return "", &CallError{Method: method, Err: ErrDisconnected}
+	}
+	c.taskId++
+	args := CallParm{
+		Id:        c.taskId,
+		SessionId: s.id,
+		Method:    method,
+		Params:    params,
+	}
+	//响应通道留一个缓冲，调用方放弃等待后监听协程也不会阻塞
+	rsp := make(chan string, 1)
+	c.taskRsp[args.Id] = rsp
+	c.lock.Unlock()
+	data, err := json.Marshal(args)
+	if err != nil {
+		c.taskCancel(args.Id)
+		return "", err
+	}
+	//发送消息
+	c.writeLock.Lock()
+	err = c.ws.WriteMessage(websocket.TextMessage, data)
+	c.writeLock.Unlock()
+	if err != nil {
+		c.taskCancel(args.Id)
+		return "", &CallError{Id: args.Id, Method: method, Err: err}
+	}
+	//等待响应消息
+	select {
+	case message := <-rsp:
+		return message, parseCDPError(message)
+	case <-s.done:
+		c.taskCancel(args.Id)
+		return "", &CallError{Id: args.Id, Method: method, Err: ErrDisconnected}
+	case <-ctx.Done():
+		c.taskCancel(args.Id)
+		err = ctx.Err()
+		if err == context.DeadlineExceeded {
+			err = ErrCallTimeout
+		}
+		return "", &CallError{Id: args.Id, Method: method, Err: err}
+	}
+}
+
+/**
+放弃等待指定任务的响应
+*/
+func (c *conn) taskCancel(id int) {
+	c.lock.Lock()
+	delete(c.taskRsp, id)
+	c.lock.Unlock()
+}
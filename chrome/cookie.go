@@ -0,0 +1,344 @@
+package chrome
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Netscape格式中表示HttpOnly的域名前缀
+const netscapeHttpOnly = "#HttpOnly_"
+
+/**
+Cookie转为Netscape格式的cookies.txt文本，curl、wget、yt-dlp等工具均可直接使用
+传参：
+	cookies：Cookie切片，可用CookiesGet获取
+返回：
+	cookies.txt文本，每行一个Cookie，字段以制表符分隔
+*/
+func CookiesToNetscape(cookies []Cookie) string {
+	var sb strings.Builder
+	sb.WriteString("# Netscape HTTP Cookie File\n")
+	for _, v := range cookies {
+		domain := v.Domain
+		if v.HTTPOnly {
+			domain = netscapeHttpOnly + domain
+		}
+		expires := int64(0)
+		if !v.Session && v.Expires > 0 {
+			expires = int64(v.Expires)
+		}
+		path := v.Path
+		if path == "" {
+			path = "/"
+		}
+		fmt.Fprintf(&sb, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, netscapeBool(strings.HasPrefix(v.Domain, ".")), path, netscapeBool(v.Secure), expires, v.Name, v.Value)
+	}
+	return sb.String()
+}
+
+/**
+解析Netscape格式的cookies.txt文本
+传参：
+	text：cookies.txt文本，忽略空行和注释行
+返回：
+	Cookie切片，可直接传给CookiesSetStu，格式错误时error返回出错的行号
+*/
+func CookiesFromNetscape(text string) ([]Cookie, error) {
+	cookies := make([]Cookie, 0)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		row := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := false
+		if strings.HasPrefix(row, netscapeHttpOnly) {
+			httpOnly = true
+			row = row[len(netscapeHttpOnly):]
+		}
+		if strings.TrimSpace(row) == "" || strings.HasPrefix(row, "#") {
+			continue
+		}
+		fields := strings.Split(row, "\t")
+		if len(fields) == 6 {
+			//值为空时部分工具会省略最后一个字段
+			fields = append(fields, "")
+		}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies.txt第%d行格式错误：应为7个以制表符分隔的字段", line)
+		}
+		expires, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies.txt第%d行过期时间错误：%v", line, err)
+		}
+		domain := fields[0]
+		if strings.EqualFold(fields[1], "TRUE") && !strings.HasPrefix(domain, ".") {
+			domain = "." + domain
+		}
+		c := Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   domain,
+			Path:     fields[2],
+			Expires:  expires,
+			Size:     len(fields[5]) + len(fields[6]),
+			HTTPOnly: httpOnly,
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Session:  expires <= 0,
+		}
+		if c.Session {
+			c.Expires = -1
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, scanner.Err()
+}
+
+/**
+Netscape格式的布尔值
+*/
+func netscapeBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+//浏览器插件（如EditThisCookie、Cookie-Editor）导出的JSON格式
+type jsonCookie struct {
+	Domain         string   `json:"domain"`
+	ExpirationDate *float64 `json:"expirationDate,omitempty"`
+	HostOnly       bool     `json:"hostOnly"`
+	HttpOnly       bool     `json:"httpOnly"`
+	Name           string   `json:"name"`
+	Path           string   `json:"path"`
+	SameSite       string   `json:"sameSite"`
+	Secure         bool     `json:"secure"`
+	Session        bool     `json:"session"`
+	Value          string   `json:"value"`
+}
+
+//插件格式与CDP格式的SameSite取值对照
+var sameSiteToJson = map[string]string{"Strict": "strict", "Lax": "lax", "None": "no_restriction"}
+
+/**
+Cookie转为浏览器插件（如EditThisCookie、Cookie-Editor）使用的JSON格式，可直接导入插件
+传参：
+	cookies：Cookie切片
+返回：
+	JSON数组文本
+*/
+func CookiesToJson(cookies []Cookie) ([]byte, error) {
+	list := make([]jsonCookie, 0, len(cookies))
+	for _, v := range cookies {
+		c := jsonCookie{
+			Domain:   v.Domain,
+			HostOnly: !strings.HasPrefix(v.Domain, "."),
+			HttpOnly: v.HTTPOnly,
+			Name:     v.Name,
+			Path:     v.Path,
+			SameSite: "unspecified",
+			Secure:   v.Secure,
+			Session:  v.Session || v.Expires <= 0,
+			Value:    v.Value,
+		}
+		if s, ok := sameSiteToJson[v.SameSite]; ok {
+			c.SameSite = s
+		}
+		if !c.Session {
+			expires := v.Expires
+			c.ExpirationDate = &expires
+		}
+		list = append(list, c)
+	}
+	return json.MarshalIndent(list, "", "\t")
+}
+
+/**
+解析JSON格式的Cookie，支持浏览器插件导出的格式（expirationDate字段），以及CookiesGet、ExportSession使用的CDP格式（expires字段）
+传参：
+	data：JSON数组文本
+返回：
+	Cookie切片，可直接传给CookiesSetStu
+*/
+func CookiesFromJson(data []byte) ([]Cookie, error) {
+	var list []struct {
+		Cookie
+		ExpirationDate *float64 `json:"expirationDate"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	cookies := make([]Cookie, 0, len(list))
+	for _, v := range list {
+		c := v.Cookie
+		if v.ExpirationDate != nil {
+			c.Expires = *v.ExpirationDate
+		}
+		for k, s := range sameSiteToJson {
+			if c.SameSite == s {
+				c.SameSite = k
+			}
+		}
+		if c.SameSite != "Strict" && c.SameSite != "Lax" && c.SameSite != "None" {
+			c.SameSite = ""
+		}
+		if c.Session || (v.ExpirationDate == nil && c.Expires == 0) {
+			c.Session = true
+			c.Expires = -1
+		}
+		if c.Size == 0 {
+			c.Size = len(c.Name) + len(c.Value)
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, nil
+}
+
+/**
+将获取或解析到的Cookie转为可设置的Cookie：未指定Url时按域名、路径补全，主机Cookie（域名不以.开头）清空Domain只按Url设置
+会话Cookie不能传过期时间，浏览器会把0当作1970年而视为已过期，由CookiesSet在发送时去掉
+*/
+func normalizeCookie(c Cookie) Cookie {
+	if c.Url == "" && c.Domain != "" {
+		scheme := "http://"
+		if c.Secure {
+			scheme = "https://"
+		}
+		c.Url = scheme + strings.TrimPrefix(c.Domain, ".") + c.Path
+	}
+	//传了domain浏览器会当作域名Cookie，主机Cookie只保留url才能保持仅限本主机
+	if c.Url != "" && !strings.HasPrefix(c.Domain, ".") {
+		c.Domain = ""
+	}
+	return c
+}
+
+/**
+Cookie转为Go标准库的*http.Cookie
+*/
+func CookieToHttp(c Cookie) *http.Cookie {
+	hc := &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly,
+	}
+	//只有域名Cookie才设置Domain，否则会被当作域名Cookie发给子域名
+	if strings.HasPrefix(c.Domain, ".") {
+		hc.Domain = c.Domain
+	}
+	if !c.Session && c.Expires > 0 {
+		sec, frac := math.Modf(c.Expires)
+		hc.Expires = time.Unix(int64(sec), int64(frac*1e9))
+	}
+	switch c.SameSite {
+	case "Strict":
+		hc.SameSite = http.SameSiteStrictMode
+	case "Lax":
+		hc.SameSite = http.SameSiteLaxMode
+	case "None":
+		hc.SameSite = http.SameSiteNoneMode
+	}
+	return hc
+}
+
+/**
+将Cookie存入Go标准库的http.CookieJar，之后big.HttpSend设置Jar即可携带这些Cookie发送请求
+传参：
+	jar：Cookie容器，如：cookiejar.New(nil)
+	cookies：Cookie切片，可用CookiesGet获取
+*/
+func CookiesToJar(jar http.CookieJar, cookies []Cookie) {
+	for _, v := range cookies {
+		scheme := "http"
+		if v.Secure {
+			scheme = "https"
+		}
+		path := v.Path
+		if path == "" {
+			path = "/"
+		}
+		u := &url.URL{Scheme: scheme, Host: strings.TrimPrefix(v.Domain, "."), Path: path}
+		jar.SetCookies(u, []*http.Cookie{CookieToHttp(v)})
+	}
+}
+
+/**
+从Go标准库的http.CookieJar中取出指定网址会携带的Cookie
+注意：http.CookieJar只提供名称和值，取出的Cookie按网址的主机名、根路径设置，均视为会话Cookie
+传参：
+	jar：Cookie容器
+	urls：网址，如：https://www.baidu.com，可传多个
+返回：
+	Cookie切片，可直接传给CookiesSetStu，网址格式错误时返回error
+*/
+func CookiesFromJar(jar http.CookieJar, urls ...string) ([]Cookie, error) {
+	cookies := make([]Cookie, 0)
+	for _, rawurl := range urls {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, err
+		}
+		for _, hc := range jar.Cookies(u) {
+			cookies = append(cookies, Cookie{
+				Name:     hc.Name,
+				Value:    hc.Value,
+				Domain:   u.Hostname(),
+				Path:     "/",
+				Expires:  -1,
+				Size:     len(hc.Name) + len(hc.Value),
+				Secure:   u.Scheme == "https",
+				Session:  true,
+				Priority: "Medium",
+				Url:      u.Scheme + "://" + u.Host + "/",
+			})
+		}
+	}
+	return cookies, nil
+}
+
+/**
+将标签所在浏览器的Cookie存入http.CookieJar，用于big.HttpSend沿用浏览器的登录状态
+传参：
+	jar：Cookie容器
+	domain：域名，格式同CookiesGet，传空字符串表示全部Cookie
+返回：
+	成功返回nil，失败返回error错误信息
+例：
+	jar, _ := cookiejar.New(nil)
+	tag.CookiesToJar(jar, "https://www.baidu.com")
+	res, _, _, _ := big.HttpSend(&big.HttpParms{Url: "https://www.baidu.com/my", Jar: jar})
+*/
+func (p *Tag) CookiesToJar(jar http.CookieJar, domain string) error {
+	cookies, _, err := p.CookiesGet(domain)
+	if err != nil {
+		return err
+	}
+	CookiesToJar(jar, cookies)
+	return nil
+}
+
+/**
+将http.CookieJar中指定网址的Cookie写入浏览器，用于big.HttpSend登录后在浏览器中继续操作
+传参：
+	jar：Cookie容器
+	urls：网址，可传多个
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *Tag) CookiesFromJar(jar http.CookieJar, urls ...string) error {
+	cookies, err := CookiesFromJar(jar, urls...)
+	if err != nil {
+		return err
+	}
+	return p.CookiesSetStu(cookies)
+}
@@ -2,14 +2,14 @@ package chrome
 
 import (
 	"b/big"
+	"context"
 	"encoding/json"
 	"github.com/bitly/go-simplejson"
-	"github.com/gorilla/websocket"
 	"strconv"
 )
 
 /**
-调用浏览器方法
+调用浏览器方法，等待时间由Tag.CallTimeout决定
 传参：
 	method：方法名
 	params：参数map类型，可用map传递多个参数
@@ -17,8 +17,27 @@ import (
 	调用结果
 */
 func (p *Tag) Call(method string, params map[string]interface{}) (string, error) {
+	timeout := p.CallTimeout
+	if timeout <= 0 {
+		timeout = DefaultCallTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return p.CallContext(ctx, method, params)
+}
+
+/**
+调用浏览器方法【可取消版】，等待响应期间响应ctx的超时和取消
+传参：
+	ctx：上下文，用于控制超时和取消
+	method：方法名
+	params：参数map类型，可用map传递多个参数
+返回
+	调用结果，超时、取消或连接断开时error为*CallError，浏览器返回错误响应时error为*CDPError
+*/
+func (p *Tag) CallContext(ctx context.Context, method string, params map[string]interface{}) (string, error) {
 	//判断标签是否连接
-	if !p.connect {
+	if !p.isConnect() {
 		//未连接，则开始连接
 		flag, err := p.Connect()
 		if !flag {
@@ -27,35 +46,18 @@ func (p *Tag) Call(method string, params map[string]interface{}) (string, error)
 		}
 	}
 	p.taskLock.Lock()
-	p.taskId++
+	s := p.session
 	p.taskLock.Unlock()
-	args := CallParm{
-		Id:     p.taskId,
-		Method: method,
-		Params: params,
-	}
-	data, err := json.Marshal(args)
-	if err != nil {
-		return "", err
-	}
-	//发送消息
-	err = p.ws.WriteMessage(websocket.TextMessage, data)
-	if err != nil {
-		return "", err
-	}
-	var message string
-	if args.Method != "Page.enable" && args.Method != "Runtime.enable" && args.Method != "Network.enable" {
-		//如果不是开启事件命令，则需要等待响应消息
-		for {
-			val, ok := p.taskRsp.Load(args.Id)
-			if ok {
-				message = val.(string)
-				p.taskRsp.Delete(args.Id)
-				break
-			}
-		}
-	}
-	return message, err
+	return s.call(ctx, method, params)
+}
+
+/**
+标签是否处于连接状态
+*/
+func (p *Tag) isConnect() bool {
+	p.taskLock.Lock()
+	defer p.taskLock.Unlock()
+	return p.session != nil && !p.session.isClosed()
 }
 
 /**
@@ -121,149 +123,155 @@ func (p *Tag) Evaluate(expression string, objectGroup string, includeCommandLine
 	isKeypad：事件是否从小键盘生成，true为是，false为否，一般为false
 	isSysttemKey：事件是否是系统键事件，true为是，false为否，一般为false
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) keyEvent(typ string, modifiers int, windowsVirtualKeyCode int, text string, isKeypad bool, isSysttemKey bool) bool {
+func (p *Tag) keyEvent(typ string, modifiers int, windowsVirtualKeyCode int, text string, isKeypad bool, isSysttemKey bool) error {
 	parm := make(map[string]interface{})
 	parm["type"] = typ
 	parm["modifiers"] = modifiers
 	_, timestamp := big.TimeStamp(19)
 	parm["timestamp"] = timestamp
+	//按美式键盘布局补全按键值和物理按键，网页可通过event.key、event.code取得
+	key, code := "", ""
+	if def := lookupKeyCode(windowsVirtualKeyCode); windowsVirtualKeyCode > 0 && def != nil {
+		key, code = def.key, def.code
+		if modifiers&8 != 0 && def.shiftKey != "" {
+			key = def.shiftKey
+		}
+	} else if def, _, ok := lookupKey(text); ok && len([]rune(text)) == 1 {
+		key, code = text, def.code
+	}
 	parm["text"] = text                                   //通过使用键盘布局处理虚拟键代码生成的文本。 keyUp和rawKeyDown事件不需要（默认：“”）
-	parm["unmodifiedText"] = ""                           //如果没有修改器被按下，则由键盘生成的文本（移位除外）。 用于快捷键（加速器）键处理（默认：“”）。
+	parm["unmodifiedText"] = text                         //如果没有修改器被按下，则由键盘生成的文本（移位除外）。 用于快捷键（加速器）键处理（默认：“”）。
 	parm["keyIdentifier"] = ""                            //唯一键标识符（例如，“U + 0041”）（默认值：“”）。
-	parm["code"] = ""                                     //每个物理键的唯一DOM定义的字符串值（例如，“KeyA”）（默认：“”）。
-	parm["key"] = ""                                      //唯一的DOM定义的字符串值，描述了活动修饰符，键盘布局等上下文中键的含义（例如，“AltGr”）（默认值：“”）。
+	parm["code"] = code                                   //每个物理键的唯一DOM定义的字符串值（例如，“KeyA”）（默认：“”）。
+	parm["key"] = key                                     //唯一的DOM定义的字符串值，描述了活动修饰符，键盘布局等上下文中键的含义（例如，“AltGr”）（默认值：“”）。
 	parm["windowsVirtualKeyCode"] = windowsVirtualKeyCode //Windows虚拟键代码（默认值：0）。
 	parm["nativeVirtualKeyCode"] = 0                      //本地虚拟键代码（默认值：0）。
 	parm["autoRepeat"] = false                            //事件是否由自动重复生成（默认值：false）。
 	parm["isKeypad"] = isKeypad                           //事件是否从小键盘生成（默认值：false）。
 	parm["isSystemKey"] = isSysttemKey                    //事件是否是系统键事件（默认值：false）。
-	res, err := p.Call("Input.dispatchKeyEvent", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Input.dispatchKeyEvent", parm)
+	return err
 }
 
 /**
-监听Chrome返回的消息
+处理标签收到的事件，在消息监听协程中同步执行
+传参：
+	method：事件名
+	jsonobj：解析后的事件消息
+	message：原始事件消息
 */
-func (p *Tag) onListenerMsg() {
-	//等待回复消息
-	for {
-		_, message, err := p.ws.ReadMessage()
-		if err != nil || p.connect == false {
-			//连接已彻底中断
-			p.connect = false
-			return
+func (p *Tag) onEventMsg(method string, jsonobj *simplejson.Json, message []byte) {
+	if p.events.has(method) {
+		//分发给订阅该事件的回调
+		params, _ := jsonobj.Get("params").MarshalJSON()
+		p.events.emit(method, params)
+	}
+	switch method {
+	case "Network.requestWillBeSent":
+		//请求拦截
+		if p.hookReqEvent != nil {
+			req := HookHttpRequest{}
+			json.Unmarshal(message, &req)
+			go p.hookReqEvent(p, req)
 		}
-		if len(message) > 0 {
-			jsonobj, err := simplejson.NewJson(message)
-			if err == nil {
-				_, flag := jsonobj.CheckGet("id")
-				if flag {
-					//如果id字段存在，说明是普通操作的响应结果
-					id, err := jsonobj.Get("id").Int()
-					if err == nil && id > 0 {
-						p.taskRsp.Store(id, string(message))
-					}
-				} else {
-					//如果id字段不存在，说明是事件的响应结果
-					method, _ := jsonobj.Get("method").String()
-					switch method {
-					case "Network.requestWillBeSent":
-						//请求拦截
-						if p.hookReqEvent != nil {
-							req := HookHttpRequest{}
-							json.Unmarshal(message, &req)
-							go p.hookReqEvent(p, req)
-						}
-					case "Network.responseReceived":
-						if p.hookRespEvent != nil {
-							resp := HookHttpResponse{}
-							json.Unmarshal(message, &resp)
-							go p.hookRespEvent(p, resp)
-						}
-					case "Runtime.executionContextCreated":
-						//V8引擎创建完毕事件，更新标签上下文ID
-						key, _ := jsonobj.Get("params").Get("context").Get("auxData").Get("frameId").String()
-						value, _ := jsonobj.Get("params").Get("context").Get("id").Int()
-						p.contextIds.Store(key, value)
-					case "Runtime.executionContextDestroyed":
-						//V8引擎销毁事件，删除上下文ID
-						id, _ := jsonobj.Get("params").Get("executionContextId").Int()
-						p.contextIds.Range(func(key, value interface{}) bool {
-							if value == id {
-								p.contextIds.Delete(key)
-								return false
-							}
-							return true
-						})
-					case "Runtime.executionContextsCleared":
-						p.contextIds.Range(func(key, value interface{}) bool {
-							p.contextIds.Delete(key)
-							return true
-						})
-					case "Page.loadEventFired":
-						p.readyState.loadEventFired = true
-						p.readyState.frameStoppedLoading = false
-						//页面加载完成时间，格式：
-						//{
-						//	"message": {
-						//	"method": "Page.loadEventFired",
-						//		"params": {
-						//		"timestamp": 42133.108263
-						//	}
-						//},
-						//	"webview": "28DAFE9FE90E9292F1B8EDB3315608EC"
-						//}
-					case "Page.frameStartedLoading":
-						p.readyState.frameStartedLoading = true
-						p.readyState.frameStoppedLoading = false
-						//框架开始加载，格式：
-						//{"message":{"method":"Page.frameStartedLoading","params":{"frameId":"49C70573CE1145CEB5B38A270213A48"}},"webview":"28DAFE9FE90E9292F1B8EDB3315608EC"}
-					case "Page.frameStoppedLoading":
-						p.readyState.frameStoppedLoading = true
-						p.pageInfoUpdate()
-						//框架停止加载
-					case "Page.javascriptDialogOpening":
-						p.haveDialog = true
-						if p.dialogOpenEvent != nil {
-							jbyte, _ := jsonobj.Get("params").MarshalJSON()
-							var dl DialogOpen
-							json.Unmarshal(jbyte, &dl)
-							p.dialogOpenEvent(dl)
-						}
-						//页面弹窗触发事件
-						//{"method":"Page.javascriptDialogOpening","params": {"url":"http://xss.php","message":"1","type":"alert","hasBrowserHandler":false,"defaultPrompt":""} }
-					case "Page.javascriptDialogClosed":
-						//页面弹窗关闭事件
-						if p.dialogCloseEvent != nil {
-							jbyte, _ := jsonobj.Get("params").MarshalJSON()
-							var dl DialogClose
-							json.Unmarshal(jbyte, &dl)
-							p.dialogCloseEvent(dl)
-						}
-						p.haveDialog = false
-					case "Runtime.consoleAPICalled":
-						//拦截控制台消息输出事件
-						p.logsLock.Lock()
-						scriptUrl, _ := jsonobj.Get("params").Get("stackTrace").Get("callFrames").GetIndex(0).Get("url").String()
-						args, _ := jsonobj.Get("params").Get("args").Array()
-						for i, _ := range args {
-							jbyte, _ := jsonobj.Get("params").Get("args").GetIndex(i).MarshalJSON()
-							var log ConsoleLog
-							json.Unmarshal(jbyte, &log)
-							log.ScriptUrl = scriptUrl
-							p.logs = append(p.logs, log)
-						}
-						p.logsLock.Unlock()
-					}
-				}
-
+	case "Network.responseReceived":
+		if p.hookRespEvent != nil {
+			resp := HookHttpResponse{}
+			json.Unmarshal(message, &resp)
+			go p.hookRespEvent(p, resp)
+		}
+	case "Fetch.requestPaused":
+		//请求被拦截暂停，交给回调决定如何放行
+		p.taskLock.Lock()
+		handler := p.fetchEvent
+		p.taskLock.Unlock()
+		if handler != nil {
+			req := &FetchRequest{tag: p}
+			jbyte, _ := jsonobj.Get("params").MarshalJSON()
+			json.Unmarshal(jbyte, req)
+			go p.fetchHandle(handler, req)
+		}
+	case "Inspector.targetCrashed", "Inspector.detached":
+		//页面崩溃或调试连接被分离，转发给浏览器级订阅者
+		params, _ := jsonobj.Get("params").MarshalJSON()
+		p.forwardInspector(method, params)
+	case "Page.fileChooserOpened":
+		//文件选择对话框被拦截，交给回调选择文件
+		p.taskLock.Lock()
+		handler := p.fileChooserEvent
+		p.taskLock.Unlock()
+		if handler != nil {
+			chooser := &FileChooser{tag: p}
+			jbyte, _ := jsonobj.Get("params").MarshalJSON()
+			json.Unmarshal(jbyte, chooser)
+			go handler(p, chooser)
+		}
+	case "Runtime.executionContextCreated":
+		//V8引擎创建完毕事件，更新标签上下文ID
+		key, _ := jsonobj.Get("params").Get("context").Get("auxData").Get("frameId").String()
+		value, _ := jsonobj.Get("params").Get("context").Get("id").Int()
+		p.contextIds.Store(key, value)
+	case "Runtime.executionContextDestroyed":
+		//V8引擎销毁事件，删除上下文ID
+		id, _ := jsonobj.Get("params").Get("executionContextId").Int()
+		p.contextIds.Range(func(key, value interface{}) bool {
+			if value == id {
+				p.contextIds.Delete(key)
+				return false
 			}
+			return true
+		})
+	case "Runtime.executionContextsCleared":
+		p.contextIds.Range(func(key, value interface{}) bool {
+			p.contextIds.Delete(key)
+			return true
+		})
+	case "Page.lifecycleEvent":
+		//框架生命周期事件，格式：
+		//{"method":"Page.lifecycleEvent","params":{"frameId":"49C70573CE1145CEB5B38A270213A48","loaderId":"9A4E1A4B9D3C0F2A6E7B8C1D2E3F4A5B","name":"load","timestamp":42133.108263}}
+		params, _ := jsonobj.Get("params").MarshalJSON()
+		p.lifecycle.onEvent(params)
+	case "Page.frameDetached":
+		//框架被移除
+		frameId, _ := jsonobj.Get("params").Get("frameId").String()
+		p.lifecycle.remove(frameId)
+	case "Page.frameStoppedLoading":
+		p.pageInfoUpdate()
+		//框架停止加载
+	case "Page.javascriptDialogOpening":
+		p.haveDialog = true
+		if p.dialogOpenEvent != nil {
+			jbyte, _ := jsonobj.Get("params").MarshalJSON()
+			var dl DialogOpen
+			json.Unmarshal(jbyte, &dl)
+			p.dialogOpenEvent(dl)
+		}
+		//页面弹窗触发事件
+		//{"method":"Page.javascriptDialogOpening","params": {"url":"http://xss.php","message":"1","type":"alert","hasBrowserHandler":false,"defaultPrompt":""} }
+	case "Page.javascriptDialogClosed":
+		//页面弹窗关闭事件
+		if p.dialogCloseEvent != nil {
+			jbyte, _ := jsonobj.Get("params").MarshalJSON()
+			var dl DialogClose
+			json.Unmarshal(jbyte, &dl)
+			p.dialogCloseEvent(dl)
 		}
+		p.haveDialog = false
+	case "Runtime.consoleAPICalled":
+		//拦截控制台消息输出事件
+		p.logsLock.Lock()
+		scriptUrl, _ := jsonobj.Get("params").Get("stackTrace").Get("callFrames").GetIndex(0).Get("url").String()
+		args, _ := jsonobj.Get("params").Get("args").Array()
+		for i, _ := range args {
+			jbyte, _ := jsonobj.Get("params").Get("args").GetIndex(i).MarshalJSON()
+			var log ConsoleLog
+			json.Unmarshal(jbyte, &log)
+			log.ScriptUrl = scriptUrl
+			p.logs = append(p.logs, log)
+		}
+		p.logsLock.Unlock()
 	}
 }
 
@@ -288,3 +296,15 @@ func (p *Tag) pageInfoUpdate() {
 		}
 	}
 }
+
+/**
+将文本转为JS字符串字面量，拼接JS代码时使用，可避免文本中的引号、换行等破坏代码
+传参：
+	s：欲转换的文本
+返回：
+	带双引号的JS字符串，如：abc'"d 转为 "abc'\"d"
+*/
+func jsQuote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,252 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//下载状态变化的内部事件名，通过浏览器事件总线分发给OnDownload和WaitDownload
+const downloadEvent = "planb.download"
+
+/**
+设置浏览器的下载行为，设置后网页触发的下载会保存到指定目录，并推送下载进度事件
+下载过程中文件以任务ID命名，下载完成后重命名为网页建议的文件名
+默认上下文和NewContext创建的上下文都会生效，之后新建或自动重启后重建的上下文也会自动设置
+传参：
+	dir：下载保存目录，不存在时自动创建，传空字符串表示恢复浏览器默认行为
+返回：
+	成功error返回nil，失败error返回具体信息
+例：
+	browser.SetDownloadBehavior("D:\\downloads")
+*/
+func (p *Browser) SetDownloadBehavior(dir string) error {
+	if dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if err = os.MkdirAll(abs, 0755); err != nil {
+			return err
+		}
+		dir = abs
+	}
+	if err := p.downloadBehavior(dir, ""); err != nil {
+		return err
+	}
+	//先记录目录，期间新建的上下文会按新目录设置
+	p.connLock.Lock()
+	p.downloadDir = dir
+	p.connLock.Unlock()
+	var err error
+	p.contexts.Range(func(key, value interface{}) bool {
+		err = p.downloadBehavior(dir, key.(*BrowserContext).Id)
+		return err == nil
+	})
+	return err
+}
+
+/**
+设置一个浏览器上下文的下载行为，不带browserContextId时只对默认上下文生效
+传参：
+	dir：下载保存目录，需为绝对路径，传空字符串表示恢复浏览器默认行为
+	contextId：浏览器上下文ID，传空字符串表示默认上下文
+*/
+func (p *Browser) downloadBehavior(dir string, contextId string) error {
+	parm := make(map[string]interface{})
+	if dir == "" {
+		parm["behavior"] = "default"
+	} else {
+		parm["behavior"] = "allowAndName"
+		parm["downloadPath"] = dir
+		parm["eventsEnabled"] = true
+	}
+	if contextId != "" {
+		parm["browserContextId"] = contextId
+	}
+	_, err := p.Call("Browser.setDownloadBehavior", parm)
+	return err
+}
+
+/**
+订阅下载进度，下载开始、进度变化、完成或取消时回调
+需先调用SetDownloadBehavior设置下载目录；回调同On，不可在回调内直接调用Call，
+下载完成的回调在重命名文件后于单独的协程中执行，可能与其他下载的回调同时执行
+传参：
+	handler：下载进度回调，可按FrameId区分发起下载的标签
+返回：
+	取消订阅的函数
+*/
+func (p *Browser) OnDownload(handler func(d Download)) (unsubscribe func()) {
+	return p.events.on(downloadEvent, func(params json.RawMessage) {
+		var d Download
+		if json.Unmarshal(params, &d) == nil {
+			handler(d)
+		}
+	})
+}
+
+/**
+处理下载事件，更新下载任务并分发给订阅者，下载完成时将文件重命名为建议的文件名
+*/
+func (p *Browser) onDownloadEvent(method string, params []byte) {
+	var ev struct {
+		Guid              string  `json:"guid"`
+		FrameId           string  `json:"frameId"`
+		Url               string  `json:"url"`
+		SuggestedFilename string  `json:"suggestedFilename"`
+		TotalBytes        float64 `json:"totalBytes"`
+		ReceivedBytes     float64 `json:"receivedBytes"`
+		State             string  `json:"state"`
+	}
+	if json.Unmarshal(params, &ev) != nil || ev.Guid == "" {
+		return
+	}
+	p.connLock.Lock()
+	dir := p.downloadDir
+	p.connLock.Unlock()
+	if p.downloads == nil {
+		p.downloads = make(map[string]*Download)
+	}
+	d := p.downloads[ev.Guid]
+	if method == "Browser.downloadWillBegin" {
+		d = &Download{
+			Guid:              ev.Guid,
+			FrameId:           ev.FrameId,
+			Url:               ev.Url,
+			SuggestedFilename: ev.SuggestedFilename,
+			Path:              filepath.Join(dir, ev.Guid),
+			State:             "inProgress",
+		}
+		p.downloads[ev.Guid] = d
+	} else {
+		if d == nil {
+			return
+		}
+		d.TotalBytes = int64(ev.TotalBytes)
+		d.ReceivedBytes = int64(ev.ReceivedBytes)
+		d.State = ev.State
+		if d.State != "inProgress" {
+			delete(p.downloads, ev.Guid)
+		}
+		if d.State == "completed" {
+			//重命名需读写磁盘，不能阻塞消息监听协程
+			go func(d Download) {
+				d.Path = renameDownload(d.Path, d.SuggestedFilename)
+				p.emitDownload(d)
+			}(*d)
+			return
+		}
+	}
+	p.emitDownload(*d)
+}
+
+/**
+将下载状态分发给订阅者
+*/
+func (p *Browser) emitDownload(d Download) {
+	if p.events.has(downloadEvent) {
+		jbyte, _ := json.Marshal(d)
+		p.events.emit(downloadEvent, jbyte)
+	}
+}
+
+/**
+将以任务ID命名的下载文件重命名为建议的文件名，同名文件已存在时在文件名后加序号，如：a (1).zip
+返回：
+	重命名后的路径，重命名失败时返回原路径
+*/
+func renameDownload(path string, name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return path
+	}
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	target := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			break
+		}
+		target = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+	}
+	if os.Rename(path, target) != nil {
+		return path
+	}
+	return target
+}
+
+/**
+执行触发下载的操作并等待本标签发起的下载完成
+需通过Browser获取标签，并先调用Browser.SetDownloadBehavior设置下载目录
+传参：
+	ctx：上下文，用于控制超时和取消
+	trigger：触发下载的操作，如点击下载链接，在开始监听后执行
+返回：
+	下载完成的任务信息，Path为保存路径，SuggestedFilename为建议的文件名，ReceivedBytes为文件大小；
+	下载被取消时error返回ErrDownloadCancel，超时或取消时error返回ctx.Err()，连接断开时返回ErrDisconnected
+例：
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	d, err := tag.WaitDownload(ctx, func() {
+		tag.ClickElement("a.download", chrome.ClickOptions{})
+	})
+*/
+func (p *Tag) WaitDownload(ctx context.Context, trigger func()) (Download, error) {
+	b := p.browser
+	if b == nil {
+		return Download{}, errors.New("标签未关联浏览器，请通过Browser获取标签")
+	}
+	if err := b.Connect(); err != nil {
+		return Download{}, err
+	}
+	b.connLock.Lock()
+	dir := b.downloadDir
+	done := b.session.done
+	b.connLock.Unlock()
+	if dir == "" {
+		return Download{}, errors.New("未设置下载目录，请先调用Browser.SetDownloadBehavior")
+	}
+	ch := make(chan Download, 1)
+	//下载完成的回调在单独的协程中执行，guid需加锁
+	var lock sync.Mutex
+	guid := ""
+	unsubscribe := b.OnDownload(func(d Download) {
+		lock.Lock()
+		defer lock.Unlock()
+		if guid == "" {
+			//只认本标签主框架或子框架发起的第一个下载
+			if d.FrameId != p.Id && !p.lifecycle.hasFrame(d.FrameId) {
+				return
+			}
+			guid = d.Guid
+		}
+		if d.Guid == guid && d.State != "inProgress" {
+			select {
+			case ch <- d:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+	if trigger != nil {
+		trigger()
+	}
+	select {
+	case d := <-ch:
+		if d.State == "canceled" {
+			return d, ErrDownloadCancel
+		}
+		return d, nil
+	case <-done:
+		return Download{}, ErrDisconnected
+	case <-ctx.Done():
+		return Download{}, ctx.Err()
+	}
+}
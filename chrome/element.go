@@ -0,0 +1,423 @@
+package chrome
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+)
+
+//在root下按选择器查找元素，all为true时返回全部匹配元素的数组，找不到时返回null或空数组，
+//选择器以xpath=、//、(//、./、../开头时按XPath查找，以text=开头时按文本查找，否则按CSS选择器查找（可加css=前缀）
+const queryJs = `function(root, selector, all) {
+	var nodes = [];
+	if (/^(xpath=|\/\/|\(\/\/|\.\.?\/)/.test(selector)) {
+		var doc = root.ownerDocument || root;
+		var snap = doc.evaluate(selector.replace(/^xpath=/, ''), root, null, XPathResult.ORDERED_NODE_SNAPSHOT_TYPE, null);
+		for (var i = 0; i < snap.snapshotLength; i++) {
+			var node = snap.snapshotItem(i);
+			//text()、@属性等非元素节点取其所属元素
+			node = node.nodeType === 1 ? node : (node.ownerElement || node.parentElement);
+			if (node && nodes.indexOf(node) === -1) {
+				nodes.push(node);
+			}
+		}
+	} else if (/^text=/.test(selector)) {
+		//text="文本"为完全匹配，text=文本为忽略大小写的包含匹配，均忽略多余空白，返回包含该文本的最内层元素
+		var text = selector.slice(5);
+		var exact = text.length > 1 && text.charAt(0) === '"' && text.charAt(text.length - 1) === '"';
+		var norm = function(s) {
+			s = s.replace(/\s+/g, ' ').trim();
+			return exact ? s : s.toLowerCase();
+		};
+		text = norm(exact ? text.slice(1, -1) : text);
+		var match = function(el) {
+			if (/^(SCRIPT|STYLE|NOSCRIPT|TEMPLATE|HEAD)$/.test(el.tagName)) {
+				return false;
+			}
+			var t = norm(el.textContent || '');
+			return exact ? t === text : t.indexOf(text) !== -1;
+		};
+		var els = root.querySelectorAll('*');
+		for (var i = 0; i < els.length; i++) {
+			if (!match(els[i])) {
+				continue;
+			}
+			var deeper = false;
+			for (var j = 0; j < els[i].children.length && !deeper; j++) {
+				deeper = match(els[i].children[j]);
+			}
+			if (!deeper) {
+				nodes.push(els[i]);
+			}
+		}
+	} else {
+		selector = selector.replace(/^css=/, '');
+		return all ? Array.prototype.slice.call(root.querySelectorAll(selector)) : root.querySelector(selector);
+	}
+	return all ? nodes : (nodes[0] || null);
+}`
+
+//Runtime域返回的远程对象
+type remoteObject struct {
+	Typ      string          `json:"type"`     //主类型：object、string、number、undefined等
+	Subtype  string          `json:"subtype"`  //子类型：node、array、null等
+	Value    json.RawMessage `json:"value"`    //按值返回时的结果
+	ObjectId string          `json:"objectId"` //按引用返回时的远程对象ID
+}
+
+/**
+生成按选择器查找单个元素的JS表达式，选择器作为字符串字面量传入，不会破坏或注入代码，所有Dom、Css系列方法都通过本方法查找元素
+传参：
+	selector：选择器
+返回：
+	JS表达式，执行结果为元素或null
+*/
+func querySelectorJs(selector string) string {
+	return "(" + queryJs + ")(document, " + jsQuote(selector) + ", false)"
+}
+
+/**
+取远程对象的文本值，字符串直接返回文本，null和undefined返回空文本，其他类型返回JSON文本
+*/
+func (o remoteObject) String() string {
+	if len(o.Value) == 0 || string(o.Value) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(o.Value, &s) == nil {
+		return s
+	}
+	return string(o.Value)
+}
+
+/**
+解析Runtime.evaluate、Runtime.callFunctionOn的返回结果，JS抛出异常时返回*JsError
+*/
+func parseRemoteObject(res string) (remoteObject, error) {
+	var rsp struct {
+		Result struct {
+			Result           remoteObject    `json:"result"`
+			ExceptionDetails json.RawMessage `json:"exceptionDetails"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(res), &rsp); err != nil {
+		return remoteObject{}, err
+	}
+	if len(rsp.Result.ExceptionDetails) > 0 {
+		return remoteObject{}, newJsError(string(rsp.Result.ExceptionDetails))
+	}
+	return rsp.Result.Result, nil
+}
+
+/**
+执行JS表达式，结果按引用返回
+传参：
+	expression：JS表达式
+	contextId：指定框架上下文id，传0表示默认主框架
+*/
+func (p *Tag) evalObject(expression string, contextId int) (remoteObject, error) {
+	parm := make(map[string]interface{})
+	parm["expression"] = expression
+	parm["awaitPromise"] = true
+	if contextId > 0 {
+		parm["contextId"] = contextId
+	}
+	res, err := p.Call("Runtime.evaluate", parm)
+	if err != nil {
+		return remoteObject{}, err
+	}
+	return parseRemoteObject(res)
+}
+
+/**
+以远程对象为this调用JS函数
+传参：
+	objectId：远程对象ID
+	fn：JS函数声明，如：function(name) { return this.getAttribute(name); }
+	returnByValue：是否按值返回结果，false时返回远程对象引用
+	args：函数参数，*ElementHandle类型按引用传入，其他类型按JSON值传入
+*/
+func (p *Tag) callFunctionOn(objectId string, fn string, returnByValue bool, args ...interface{}) (remoteObject, error) {
+	arguments := make([]map[string]interface{}, 0, len(args))
+	for _, v := range args {
+		if h, ok := v.(*ElementHandle); ok {
+			arguments = append(arguments, map[string]interface{}{"objectId": h.ObjectId})
+		} else {
+			arguments = append(arguments, map[string]interface{}{"value": v})
+		}
+	}
+	parm := make(map[string]interface{})
+	parm["objectId"] = objectId
+	parm["functionDeclaration"] = fn
+	parm["arguments"] = arguments
+	parm["returnByValue"] = returnByValue
+	parm["awaitPromise"] = true
+	res, err := p.Call("Runtime.callFunctionOn", parm)
+	if err != nil {
+		return remoteObject{}, err
+	}
+	return parseRemoteObject(res)
+}
+
+/**
+将数组远程对象拆分为元素句柄切片，拆分后释放数组对象
+*/
+func (p *Tag) arrayToHandles(array remoteObject) ([]*ElementHandle, error) {
+	parm := make(map[string]interface{})
+	parm["objectId"] = array.ObjectId
+	parm["ownProperties"] = true
+	res, err := p.Call("Runtime.getProperties", parm)
+	p.releaseObject(array.ObjectId)
+	if err != nil {
+		return nil, err
+	}
+	var rsp struct {
+		Result struct {
+			Result []struct {
+				Name  string       `json:"name"`
+				Value remoteObject `json:"value"`
+			} `json:"result"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return nil, err
+	}
+	handles := make([]*ElementHandle, 0, len(rsp.Result.Result))
+	for _, v := range rsp.Result.Result {
+		//跳过length等非元素属性
+		if v.Value.Subtype == "node" && v.Value.ObjectId != "" {
+			handles = append(handles, &ElementHandle{ObjectId: v.Value.ObjectId, tag: p})
+		}
+	}
+	return handles, nil
+}
+
+/**
+释放远程对象
+*/
+func (p *Tag) releaseObject(objectId string) error {
+	_, err := p.Call("Runtime.releaseObject", map[string]interface{}{"objectId": objectId})
+	return err
+}
+
+/**
+在主框架中查找第一个匹配的元素
+传参：
+	selector：选择器，可包含任意引号等特殊字符，所有接受选择器的方法都支持以下格式：
+		CSS选择器：如 #login .btn，也可加css=前缀
+		XPath：以xpath=、//、(//、./、../开头，如 //a[contains(@href,'next')]，以./开头时相对于查找起点
+		文本匹配：以text=开头，如 text=下一页 忽略大小写包含匹配，text="下一页" 完全匹配，返回包含该文本的最内层元素，
+			GBK编码网页的文本无需转码，页面中的文本已由浏览器解码，从GBK源文件读取的文本需先用big.EnCodeGbkToUtf8转为UTF-8
+返回：
+	元素句柄，未找到时返回ErrNotFound，选择器错误时返回*JsError
+*/
+func (p *Tag) Query(selector string) (*ElementHandle, error) {
+	return p.QueryFrame(0, selector)
+}
+
+/**
+在指定框架中查找第一个匹配的元素
+传参：
+	contextId：指定框架上下文id，传0表示默认主框架
+	selector：选择器，支持CSS、XPath、文本匹配，格式见Query
+返回：
+	元素句柄，未找到时返回ErrNotFound，选择器错误时返回*JsError
+*/
+func (p *Tag) QueryFrame(contextId int, selector string) (*ElementHandle, error) {
+	obj, err := p.evalObject(querySelectorJs(selector), contextId)
+	if err != nil {
+		return nil, err
+	}
+	if obj.ObjectId == "" {
+		return nil, ErrNotFound
+	}
+	return &ElementHandle{ObjectId: obj.ObjectId, tag: p}, nil
+}
+
+/**
+在主框架中查找全部匹配的元素
+传参：
+	selector：选择器，支持CSS、XPath、文本匹配，格式见Query
+返回：
+	元素句柄切片，未找到时返回空切片，选择器错误时返回*JsError
+*/
+func (p *Tag) QueryAll(selector string) ([]*ElementHandle, error) {
+	return p.QueryAllFrame(0, selector)
+}
+
+/**
+在指定框架中查找全部匹配的元素
+传参：
+	contextId：指定框架上下文id，传0表示默认主框架
+	selector：选择器，支持CSS、XPath、文本匹配，格式见Query
+返回：
+	元素句柄切片，未找到时返回空切片，选择器错误时返回*JsError
+*/
+func (p *Tag) QueryAllFrame(contextId int, selector string) ([]*ElementHandle, error) {
+	obj, err := p.evalObject("("+queryJs+")(document, "+jsQuote(selector)+", true)", contextId)
+	if err != nil {
+		return nil, err
+	}
+	return p.arrayToHandles(obj)
+}
+
+/**
+在本元素内查找第一个匹配的子元素
+传参：
+	selector：选择器，支持CSS、XPath、文本匹配，格式见Query，XPath以./开头时相对于本元素
+返回：
+	元素句柄，未找到时返回ErrNotFound，选择器错误时返回*JsError
+*/
+func (p *ElementHandle) Query(selector string) (*ElementHandle, error) {
+	obj, err := p.tag.callFunctionOn(p.ObjectId, "function(selector) {\n\treturn ("+queryJs+")(this, selector, false);\n}", false, selector)
+	if err != nil {
+		return nil, err
+	}
+	if obj.ObjectId == "" {
+		return nil, ErrNotFound
+	}
+	return &ElementHandle{ObjectId: obj.ObjectId, tag: p.tag}, nil
+}
+
+/**
+在本元素内查找全部匹配的子元素
+传参：
+	selector：选择器，支持CSS、XPath、文本匹配，格式见Query，XPath以./开头时相对于本元素
+返回：
+	元素句柄切片，未找到时返回空切片，选择器错误时返回*JsError
+*/
+func (p *ElementHandle) QueryAll(selector string) ([]*ElementHandle, error) {
+	obj, err := p.tag.callFunctionOn(p.ObjectId, "function(selector) {\n\treturn ("+queryJs+")(this, selector, true);\n}", false, selector)
+	if err != nil {
+		return nil, err
+	}
+	return p.tag.arrayToHandles(obj)
+}
+
+/**
+以本元素为this执行JS函数，结果按值返回
+传参：
+	fn：JS函数声明，如：function(name) { return this.dataset[name]; }
+	args：函数参数，*ElementHandle类型按引用传入，其他类型按JSON值传入
+返回：
+	执行结果，字符串直接返回文本，null和undefined返回空文本，其他类型返回JSON文本，JS抛出异常时返回*JsError
+*/
+func (p *ElementHandle) Eval(fn string, args ...interface{}) (string, error) {
+	obj, err := p.tag.callFunctionOn(p.ObjectId, fn, true, args...)
+	if err != nil {
+		return "", err
+	}
+	return obj.String(), nil
+}
+
+/**
+取元素文本（innerText）
+返回：
+	元素文本，失败时error返回具体信息
+*/
+func (p *ElementHandle) Text() (string, error) {
+	return p.Eval("function() {\n\treturn this.innerText === undefined ? this.textContent : this.innerText;\n}")
+}
+
+/**
+取元素属性值，属性不存在时返回空文本
+传参：
+	name：属性名
+返回：
+	属性值，失败时error返回具体信息
+*/
+func (p *ElementHandle) Attr(name string) (string, error) {
+	return p.Eval("function(name) {\n\treturn this.getAttribute(name);\n}", name)
+}
+
+/**
+取元素值（value），一般用于input、textarea、select
+返回：
+	元素值，失败时error返回具体信息
+*/
+func (p *ElementHandle) Value() (string, error) {
+	return p.Eval("function() {\n\treturn this.value;\n}")
+}
+
+/**
+滚动页面使元素可见，元素已可见时不滚动
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *ElementHandle) ScrollIntoView() error {
+	_, err := p.tag.Call("DOM.scrollIntoViewIfNeeded", map[string]interface{}{"objectId": p.ObjectId})
+	var cdpErr *CDPError
+	if errors.As(err, &cdpErr) {
+		//旧版浏览器不支持DOM.scrollIntoViewIfNeeded，改用JS滚动
+		_, err = p.Eval("function() {\n\tthis.scrollIntoView({block: 'center', inline: 'center'});\n}")
+	}
+	return err
+}
+
+/**
+取元素边框区域，坐标相对于主框架视口
+返回：
+	元素所在矩形区域，元素不可见（如display:none）时浏览器返回*CDPError
+*/
+func (p *ElementHandle) BoundingBox() (Rect, error) {
+	res, err := p.tag.Call("DOM.getBoxModel", map[string]interface{}{"objectId": p.ObjectId})
+	if err != nil {
+		return Rect{}, err
+	}
+	var rsp struct {
+		Result struct {
+			Model struct {
+				Border []float64 `json:"border"`
+			} `json:"model"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return Rect{}, err
+	}
+	//border为四个角的坐标：x1,y1,x2,y2,x3,y3,x4,y4，元素旋转时不是矩形，取外接矩形
+	quad := rsp.Result.Model.Border
+	if len(quad) < 8 {
+		return Rect{}, ErrUnsupported
+	}
+	minX, minY := math.MaxFloat64, math.MaxFloat64
+	maxX, maxY := -math.MaxFloat64, -math.MaxFloat64
+	for i := 0; i < 8; i += 2 {
+		minX = math.Min(minX, quad[i])
+		maxX = math.Max(maxX, quad[i])
+		minY = math.Min(minY, quad[i+1])
+		maxY = math.Max(maxY, quad[i+1])
+	}
+	return Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}, nil
+}
+
+/**
+鼠标左键单击元素中心，会先滚动页面使元素可见，与DomClick不同的是本方法会产生真实的鼠标事件
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *ElementHandle) Click() error {
+	return p.ClickWith(ClickOptions{})
+}
+
+/**
+聚焦元素并模拟键盘输入文本，文本追加到元素现有内容之后，按键间使用默认延迟，如需更多控制请聚焦后调用Tag.Type
+传参：
+	text：欲输入的文本
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *ElementHandle) Type(text string) error {
+	if _, err := p.Eval("function() {\n\tthis.focus();\n}"); err != nil {
+		return err
+	}
+	return p.tag.Type(text, TypeOptions{})
+}
+
+/**
+释放元素句柄，释放后不可再使用，页面跳转后句柄会自动失效
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *ElementHandle) Release() error {
+	return p.tag.releaseObject(p.ObjectId)
+}
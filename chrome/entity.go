@@ -1,5 +1,10 @@
 package chrome
 
+import (
+	"encoding/json"
+	"time"
+)
+
 //标签上下文
 type TagContext struct {
 	Id      int    `json:"id"`
@@ -10,9 +15,10 @@ type TagContext struct {
 
 //调用浏览器方法的参数
 type CallParm struct {
-	Id     int                    `json:"id"`
-	Method string                 `json:"method"`
-	Params map[string]interface{} `json:"params"`
+	Id        int                    `json:"id"`
+	SessionId string                 `json:"sessionId,omitempty"` //目标会话ID，flatten模式下通过浏览器连接操作标签时使用
+	Method    string                 `json:"method"`
+	Params    map[string]interface{} `json:"params"`
 }
 
 //页面框架
@@ -50,11 +56,11 @@ type EvalRes struct {
 	ExceptionDetails string `json:"exceptionDetails"` //异常信息，当执行出现错误后本属性有值
 }
 
-//框架页面加载进度
-type frameLoadStep struct {
-	loadEventFired      bool //页面开始加载
-	frameStartedLoading bool //框架开始加载
-	frameStoppedLoading bool //框架停止加载
+//WaitForSelector的等待条件
+type WaitOptions struct {
+	Visible bool //等待元素存在且可见（非display:none、visibility:hidden，且有宽高）
+	Hidden  bool //等待元素不存在或不可见，不可与Visible同时为true
+	Frame   int  //指定框架上下文id，传0表示默认主框架
 }
 
 //对话框创建时的信息
@@ -240,3 +246,213 @@ type HookHttpResponse struct {
 		FrameId string `json:"frameId"` //页面框架ID
 	} `json:"params"`
 }
+
+//目标信息，目标可以是页面、内嵌页、插件后台页、Service Worker等
+type TargetInfo struct {
+	TargetId         string `json:"targetId"`         //目标ID，页面目标的ID与Tag.Id相同
+	Typ              string `json:"type"`             //目标类型：page（页面）、background_page（插件）、iframe（内嵌页）、service_worker、shared_worker、browser等
+	Title            string `json:"title"`            //标题
+	Url              string `json:"url"`              //链接
+	Attached         bool   `json:"attached"`         //是否已有调试客户端附加到该目标
+	OpenerId         string `json:"openerId"`         //打开该目标的目标ID，弹窗页面此项有值
+	BrowserContextId string `json:"browserContextId"` //所属浏览器上下文ID
+}
+
+//请求拦截规则，用于HookFetchEn
+type FetchPattern struct {
+	UrlPattern   string `json:"urlPattern,omitempty"`   //网址通配符，支持*（任意个字符）和?（单个字符），如：*.png，为空表示全部网址
+	ResourceType string `json:"resourceType,omitempty"` //资源类型：Document、Stylesheet、Image、Media、Font、Script、XHR、Fetch、WebSocket、Other等，为空表示全部类型
+	RequestStage string `json:"requestStage,omitempty"` //拦截阶段：Request（请求发出前，默认）、Response（收到响应头后，可读取并替换响应内容）
+}
+
+//协议头条目
+type HeaderEntry struct {
+	Name  string `json:"name"`  //协议头名称
+	Value string `json:"value"` //协议头值
+}
+
+//被拦截暂停的请求，需调用Continue、ContinueWith、Fulfill、Fail其中之一放行，回调未处理时会自动调用Continue
+type FetchRequest struct {
+	RequestId string   `json:"requestId"` //拦截ID，仅在本次拦截中有效
+	Request   struct { //请求主体内容
+		Url         string            `json:"url"`         //请求URL
+		Method      string            `json:"method"`      //请求模式：GET POST
+		Headers     map[string]string `json:"headers"`     //Head信息
+		PostData    string            `json:"postData"`    //POST数据内容
+		HasPostData bool              `json:"hasPostData"` //是否有POST数据
+	} `json:"request"`
+	FrameId             string        `json:"frameId"`             //页面框架ID
+	ResourceType        string        `json:"resourceType"`        //资源类型：Document、Image、Font、XHR等
+	ResponseErrorReason string        `json:"responseErrorReason"` //响应阶段拦截时，请求失败的原因
+	ResponseStatusCode  int           `json:"responseStatusCode"`  //响应阶段拦截时的响应状态码，请求阶段为0
+	ResponseStatusText  string        `json:"responseStatusText"`  //响应阶段拦截时的响应状态描述
+	ResponseHeaders     []HeaderEntry `json:"responseHeaders"`     //响应阶段拦截时的响应协议头
+	NetworkId           string        `json:"networkId"`           //对应Network域的requestId，可与HookHttpEn拦截到的请求配对
+	tag                 *Tag          //所属标签
+	handled             bool          //是否已放行
+}
+
+//修改后继续请求的参数，字段为空表示不修改
+type FetchContinue struct {
+	Url      string            //新的请求URL，修改后页面不会察觉到重定向
+	Method   string            //新的请求模式
+	PostData []byte            //新的POST数据
+	Headers  map[string]string //新的协议头，会整体替换原协议头
+}
+
+//元素句柄，通过Tag.Query或Tag.QueryAll取得，持有页面中元素的远程对象引用，页面跳转后失效
+type ElementHandle struct {
+	ObjectId string //远程对象ID
+	tag      *Tag   //所属标签
+}
+
+//被拦截的文件选择对话框，调用SetFiles选择文件，不调用则相当于取消选择
+type FileChooser struct {
+	FrameId       string `json:"frameId"`       //打开对话框的框架ID
+	Mode          string `json:"mode"`          //选择模式：selectSingle（单选）、selectMultiple（多选）
+	BackendNodeId int    `json:"backendNodeId"` //对应的input[type=file]元素的节点ID
+	tag           *Tag   //所属标签
+}
+
+//矩形区域，坐标相对于主框架视口，单位为CSS像素
+type Rect struct {
+	X      float64 `json:"x"`      //左上角X坐标
+	Y      float64 `json:"y"`      //左上角Y坐标
+	Width  float64 `json:"width"`  //宽度
+	Height float64 `json:"height"` //高度
+}
+
+//鼠标点击选项，用于ClickElement，字段为零值时使用默认值
+type ClickOptions struct {
+	Button     string        //鼠标按键，可选值：left（默认）、right、middle
+	ClickCount int           //点击次数，默认1，传2为双击
+	OffsetX    float64       //点击位置相对于元素中心的X偏移，单位CSS像素
+	OffsetY    float64       //点击位置相对于元素中心的Y偏移，单位CSS像素
+	Modifiers  int           //功能键，可选值：Alt = 1，Ctrl = 2，Meta/Command = 4，Shift = 8，可相加
+	Delay      time.Duration //按下与松开之间的间隔，默认不等待
+	Track      Trajectory    //鼠标移动到元素的轨迹，传nil表示使用DefaultTrack
+	Frame      int           //指定框架上下文id，传0表示默认主框架
+}
+
+//鼠标轨迹点，由Trajectory生成
+type TrackPoint struct {
+	X        int //X坐标，相对于主框架视口
+	Y        int //Y坐标，相对于主框架视口
+	MinDelay int //移动到本点前的最小延迟，单位毫秒
+	MaxDelay int //移动到本点前的最大延迟，单位毫秒，实际延迟在MinDelay和MaxDelay之间随机
+}
+
+//模拟键盘输入选项，用于Type，字段为零值时使用默认值
+type TypeOptions struct {
+	MinDelay int     //按键间最小延迟，单位毫秒，与MaxDelay均为0时默认50~150毫秒
+	MaxDelay int     //按键间最大延迟，单位毫秒，小于0表示不延迟
+	TypoRate float64 //输错概率，0~1之间，输错后会停顿并按退格键删除再输入正确字符，传0表示不输错
+}
+
+//截图选项，用于Screenshot，字段为零值时使用默认值
+type ScreenshotOptions struct {
+	Format         string //图片格式，可选值：png（默认）、jpeg、webp
+	Quality        int    //压缩质量，取值范围0-100，仅jpeg、webp格式有效
+	FullPage       bool   //是否截取整个页面，包括需要滚动才能看到的部分
+	Selector       string //截取匹配该选择器的元素，支持CSS、XPath、文本匹配，格式见Query
+	Frame          int    //Selector所在框架的上下文id，传0表示默认主框架
+	Clip           *Rect  //截取指定区域，坐标相对于整个页面左上角，FullPage、Selector、Clip同时设置时优先级为Selector、Clip、FullPage
+	OmitBackground bool   //是否去掉网页默认的白色背景，使截图背景透明，仅png、webp格式有效
+}
+
+//导出PDF选项，用于PrintPDF，字段为零值时使用默认值
+type PdfOptions struct {
+	Format              string  //纸张规格，可选值：Letter（默认）、Legal、Tabloid、Ledger、A0~A6，设置后PaperWidth、PaperHeight无效
+	PaperWidth          float64 //纸张宽度，单位英寸
+	PaperHeight         float64 //纸张高度，单位英寸
+	Landscape           bool    //是否横向
+	MarginTop           float64 //上边距，单位英寸，默认0
+	MarginBottom        float64 //下边距，单位英寸，默认0
+	MarginLeft          float64 //左边距，单位英寸，默认0
+	MarginRight         float64 //右边距，单位英寸，默认0
+	Scale               float64 //缩放比例，取值范围0.1-2，默认1
+	PageRanges          string  //打印的页码范围，如：1-5, 8, 11-13，默认全部
+	PrintBackground     bool    //是否打印背景图片和背景色
+	DisplayHeaderFooter bool    //是否显示页眉页脚
+	HeaderTemplate      string  //页眉HTML模板，可用class为date、title、url、pageNumber、totalPages的元素插入对应内容，如：<span class="title"></span>
+	FooterTemplate      string  //页脚HTML模板，格式同HeaderTemplate
+	PreferCSSPageSize   bool    //是否优先使用网页CSS中@page定义的纸张尺寸
+}
+
+//录屏选项，用于StartScreencast，Dir和File必须且只能设置一个
+type ScreencastOptions struct {
+	Dir           string //保存帧图片的目录，不存在时自动创建，文件名格式为：序号_毫秒时间戳.jpeg
+	File          string //保存的录屏文件，扩展名为.mjpeg、.mjpg时保存为MJPEG，为.png、.apng时保存为APNG动画
+	Format        string //帧图片格式，可选值：jpeg（默认）、png，仅Dir有效，File按扩展名决定格式
+	Quality       int    //jpeg压缩质量，取值范围0-100
+	MaxWidth      int    //帧的最大宽度，超过时按比例缩小，传0表示不限制
+	MaxHeight     int    //帧的最大高度，超过时按比例缩小，传0表示不限制
+	EveryNthFrame int    //每隔几帧取一帧，传0表示每帧都取
+}
+
+//下载任务信息
+type Download struct {
+	Guid              string `json:"guid"`              //下载任务ID
+	FrameId           string `json:"frameId"`           //发起下载的框架ID
+	Url               string `json:"url"`               //下载地址
+	SuggestedFilename string `json:"suggestedFilename"` //网页建议的文件名
+	Path              string `json:"path"`              //保存路径，下载完成后会由任务ID重命名为建议的文件名，同名文件已存在时自动加序号
+	TotalBytes        int64  `json:"totalBytes"`        //文件总大小，单位字节，未知时为0
+	ReceivedBytes     int64  `json:"receivedBytes"`     //已下载大小，单位字节
+	State             string `json:"state"`             //下载状态：inProgress（下载中）、completed（已完成）、canceled（已取消）
+}
+
+//浏览器池选项
+type PoolOptions struct {
+	Size           int             //浏览器数量，传0表示默认1个
+	MaxTabs        int             //每个浏览器同时租出的标签数上限，传0表示不限
+	MaxUses        int             //每个浏览器累计租出的次数上限，达到后不再租出，全部归还后关闭并启动新浏览器，传0表示不限
+	Incognito      bool            //是否为每次租用创建独立的无痕上下文，上下文间Cookie、缓存等互相隔离，归还时一并销毁
+	HealthInterval time.Duration   //健康检查间隔，通过/json/version检查浏览器是否可用，不可用时自动替换，传0表示默认30秒
+	New            func() *Browser //创建浏览器对象的方法，可在其中设置Headless、Proxy等属性，OpenBrowser由浏览器池调用，传nil表示默认Hide为true的浏览器
+}
+
+//从浏览器池租用的标签，用完后需调用BrowserPool.Release归还
+type PoolLease struct {
+	Tag      *Tag            //租用的标签，通过浏览器附加
+	Browser  *Browser        //标签所属的浏览器
+	Context  *BrowserContext //无痕上下文，未开启Incognito时为nil
+	entry    *poolEntry
+	released bool
+}
+
+//浏览器上下文选项
+type ContextOptions struct {
+	ProxyServer     string //本上下文使用的代理，格式：127.0.0.1:8888、http://proxy.com:8080、socks5://127.0.0.1:1080，不支持账号密码，可空，默认使用浏览器的代理
+	ProxyBypassList string //不走代理的地址列表，以英文逗号分隔，如：localhost,*.baidu.com
+}
+
+//浏览器上下文，相当于一个独立的无痕窗口，上下文之间Cookie、缓存、本地存储等互相隔离，由NewContext创建
+type BrowserContext struct {
+	Id      string         //浏览器上下文ID，自动重启浏览器后变为重建的上下文ID
+	browser *Browser       //所属浏览器
+	opts    ContextOptions //创建时的选项，自动重启浏览器后按此重建上下文
+}
+
+//会话档案，包含登录状态所需的Cookie和各源的存储数据，可保存为JSON文件后在其他电脑导入
+type SessionProfile struct {
+	Version   int             `json:"version"`   //档案格式版本，导出时为SessionProfileVersion
+	Created   time.Time       `json:"created"`   //导出时间
+	UserAgent string          `json:"userAgent"` //导出时浏览器的UserAgent，换电脑导入后可设置相同的UserAgent，降低被网站要求重新登录的概率
+	Cookies   []Cookie        `json:"cookies"`   //浏览器的全部Cookie
+	Origins   []OriginStorage `json:"origins"`   //各源的存储数据
+}
+
+//单个源的存储数据
+type OriginStorage struct {
+	Origin         string            `json:"origin"`                   //源，如：https://www.baidu.com
+	LocalStorage   map[string]string `json:"localStorage,omitempty"`   //localStorage数据
+	SessionStorage map[string]string `json:"sessionStorage,omitempty"` //sessionStorage数据，仅对导出时本标签打开的源有效
+	IndexedDB      json.RawMessage   `json:"indexedDB,omitempty"`      //IndexedDB数据库及其全部记录，仅导出页面当前所在的源
+}
+
+//导出会话档案的选项
+type SessionOptions struct {
+	Origins   []string //额外导出存储数据的源，如：https://www.baidu.com，需在本标签的某个框架中打开，未打开的源会被跳过，默认只导出本标签各框架当前所在的源
+	IndexedDB bool     //是否导出IndexedDB，只能导出页面当前所在源的数据库，记录的值需可转为JSON，Date、Blob等类型会丢失
+}
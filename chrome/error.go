@@ -0,0 +1,124 @@
+package chrome
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//Call方法默认的等待响应超时时间
+const DefaultCallTimeout = 30 * time.Second
+
+var (
+	ErrCallTimeout    = errors.New("等待浏览器响应超时")
+	ErrDisconnected   = errors.New("与浏览器的连接已断开")
+	ErrInvalidParam   = errors.New("参数错误")
+	ErrLoadTimeout    = errors.New("等待页面加载完成超时")
+	ErrUnsupported    = errors.New("浏览器不支持本操作")
+	ErrCookieRejected = errors.New("浏览器拒绝设置该Cookie")
+	ErrNotFound       = errors.New("未找到匹配的元素")
+	ErrDownloadCancel = errors.New("下载已取消")
+	ErrBrowserClosed  = errors.New("浏览器已关闭")
+	ErrBrowserExited  = errors.New("浏览器进程异常退出")
+	ErrPoolClosed     = errors.New("浏览器池已关闭")
+)
+
+//导入会话档案时部分源的存储数据导入失败，Cookie和其余源的数据已导入
+type SessionImportError struct {
+	Origins map[string]error //导入失败的源及失败原因
+}
+
+func (e *SessionImportError) Error() string {
+	origins := make([]string, 0, len(e.Origins))
+	for k := range e.Origins {
+		origins = append(origins, k)
+	}
+	sort.Strings(origins)
+	res := "部分源的存储数据导入失败"
+	for _, v := range origins {
+		res += "；" + v + "：" + e.Origins[v].Error()
+	}
+	return res
+}
+
+//调用浏览器方法失败时的错误信息，可用errors.Is判断Err是ErrCallTimeout、ErrDisconnected还是context.Canceled
+type CallError struct {
+	Id     int    //任务ID，消息未发出时为0
+	Method string //调用的方法名
+	Err    error  //失败原因
+}
+
+func (e *CallError) Error() string {
+	return e.Method + "调用失败：" + e.Err.Error()
+}
+
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+//是否因等待超时失败
+func (e *CallError) Timeout() bool {
+	return e.Err == ErrCallTimeout
+}
+
+//浏览器返回的错误响应，格式：{"id":1,"error":{"code":-32000,"message":"Cannot navigate to invalid URL","data":"..."}}
+type CDPError struct {
+	Code    int    `json:"code"`    //错误码，-32000为通用服务端错误，-32601为方法不存在，-32602为参数错误
+	Message string `json:"message"` //错误信息
+	Data    string `json:"data"`    //附加信息，可空
+}
+
+func (e *CDPError) Error() string {
+	res := "CDP错误(" + strconv.Itoa(e.Code) + ")：" + e.Message
+	if e.Data != "" {
+		res += "，" + e.Data
+	}
+	return res
+}
+
+//JS代码执行时抛出的异常，格式参考Runtime.ExceptionDetails
+type JsError struct {
+	Text    string //异常描述，一般为"Uncaught"加上异常信息，如：Uncaught TypeError: Cannot read properties of null (reading 'click')
+	Details string //完整的异常信息JSON
+}
+
+func (e *JsError) Error() string {
+	return "JS执行异常：" + e.Text
+}
+
+//导航失败，如域名解析失败、连接被拒绝等
+type NavigateError struct {
+	Url       string //导航的网址
+	ErrorText string //浏览器返回的失败原因，如：net::ERR_NAME_NOT_RESOLVED
+}
+
+func (e *NavigateError) Error() string {
+	return "打开网址失败：" + e.Url + "，" + e.ErrorText
+}
+
+/**
+解析响应消息中的error字段
+传参：
+	message：浏览器返回的响应消息
+返回：
+	存在error字段时返回*CDPError，否则返回nil
+*/
+func parseCDPError(message string) error {
+	var rsp struct {
+		Error *struct {
+			Code    int             `json:"code"`
+			Message string          `json:"message"`
+			Data    json.RawMessage `json:"data"`
+		} `json:"error"`
+	}
+	if json.Unmarshal([]byte(message), &rsp) != nil || rsp.Error == nil {
+		return nil
+	}
+	cdpErr := &CDPError{Code: rsp.Error.Code, Message: rsp.Error.Message}
+	if len(rsp.Error.Data) > 0 && json.Unmarshal(rsp.Error.Data, &cdpErr.Data) != nil {
+		cdpErr.Data = string(rsp.Error.Data)
+	}
+	return cdpErr
+}
@@ -0,0 +1,151 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+//事件订阅总线，按事件名分发浏览器推送的事件
+type eventBus struct {
+	lock     sync.Mutex
+	id       int                                             //订阅ID，自增
+	handlers map[string]map[int]func(params json.RawMessage) //订阅者集合，key是事件名，value的key是订阅ID
+}
+
+/**
+订阅事件
+传参：
+	method：事件名，如：Page.downloadWillBegin
+	handler：事件回调，形参为事件的params字段
+返回：
+	取消订阅的函数，可重复调用
+*/
+func (b *eventBus) on(method string, handler func(params json.RawMessage)) func() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.handlers == nil {
+		b.handlers = make(map[string]map[int]func(params json.RawMessage))
+	}
+	if b.handlers[method] == nil {
+		b.handlers[method] = make(map[int]func(params json.RawMessage))
+	}
+	b.id++
+	id := b.id
+	b.handlers[method][id] = handler
+	return func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		delete(b.handlers[method], id)
+		if len(b.handlers[method]) == 0 {
+			delete(b.handlers, method)
+		}
+	}
+}
+
+/**
+事件是否有订阅者
+*/
+func (b *eventBus) has(method string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.handlers[method]) > 0
+}
+
+/**
+分发事件给全部订阅者，回调在锁外按订阅顺序执行，回调内可以取消订阅
+*/
+func (b *eventBus) emit(method string, params json.RawMessage) {
+	b.lock.Lock()
+	ids := make([]int, 0, len(b.handlers[method]))
+	for id := range b.handlers[method] {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	handlers := make([]func(params json.RawMessage), 0, len(ids))
+	for _, id := range ids {
+		handlers = append(handlers, b.handlers[method][id])
+	}
+	b.lock.Unlock()
+	for _, handler := range handlers {
+		handler(params)
+	}
+}
+
+/**
+等待满足条件的事件
+传参：
+	ctx：上下文，用于控制超时和取消
+	done：连接断开通知通道，关闭后立即返回ErrDisconnected，可传nil
+	method：事件名
+	predicate：过滤条件，返回true表示就是要等的事件，传nil表示收到该事件即可
+返回：
+	事件的params字段，超时或取消时error返回ctx.Err()
+*/
+func (b *eventBus) waitFor(ctx context.Context, done <-chan struct{}, method string, predicate func(params json.RawMessage) bool) (json.RawMessage, error) {
+	ch := make(chan json.RawMessage, 1)
+	unsubscribe := b.on(method, func(params json.RawMessage) {
+		if predicate != nil && !predicate(params) {
+			return
+		}
+		select {
+		case ch <- params:
+		default:
+		}
+	})
+	defer unsubscribe()
+	select {
+	case params := <-ch:
+		return params, nil
+	case <-done:
+		return nil, ErrDisconnected
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+/**
+订阅标签事件，可订阅任意CDP事件，如：Page.downloadWillBegin、Target.targetCreated、Network.webSocketFrameReceived
+注意：事件所属的域需先开启，如Network域事件需先调用Network.enable；
+回调在消息监听协程中同步执行，回调内不可直接调用Call等需等待响应的方法，否则将阻塞至超时，如需调用请另开协程
+传参：
+	method：事件名
+	handler：事件回调，形参为事件的params字段，可用json.Unmarshal解析为需要的结构体
+返回：
+	取消订阅的函数
+*/
+func (p *Tag) On(method string, handler func(params json.RawMessage)) (unsubscribe func()) {
+	return p.events.on(method, handler)
+}
+
+/**
+等待标签事件，未连接时会先自动连接
+传参：
+	ctx：上下文，用于控制超时和取消
+	method：事件名
+	predicate：过滤条件，返回true表示就是要等的事件，传nil表示收到该事件即可
+返回：
+	事件的params字段，超时或取消时error返回ctx.Err()，连接断开时返回ErrDisconnected
+*/
+func (p *Tag) WaitForEvent(ctx context.Context, method string, predicate func(params json.RawMessage) bool) (json.RawMessage, error) {
+	done, err := p.sessionDone()
+	if err != nil {
+		return nil, err
+	}
+	return p.events.waitFor(ctx, done, method, predicate)
+}
+
+/**
+取会话结束通知通道，未连接时会先自动连接，供各等待方法使用
+*/
+func (p *Tag) sessionDone() (<-chan struct{}, error) {
+	if !p.isConnect() {
+		if _, err := p.Connect(); err != nil {
+			return nil, err
+		}
+	}
+	p.taskLock.Lock()
+	defer p.taskLock.Unlock()
+	return p.session.done, nil
+}
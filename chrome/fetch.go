@@ -0,0 +1,190 @@
+package chrome
+
+import (
+	"b/big"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+/**
+开启拦截并修改网络请求，与HookHttpEn不同的是本方法可以修改、伪造或拦截请求
+被拦截的请求会暂停，直到回调中调用FetchRequest的Continue、ContinueWith、Fulfill、Fail其中之一，回调未处理时会自动放行
+传参：
+	patterns：拦截规则，可按网址通配符、资源类型过滤，传nil表示拦截全部请求
+	handler：传入格式为func(tag *chrome.Tag, req *chrome.FetchRequest)的函数，当有请求被拦截时会在新协程中自动触发
+返回：
+	成功返回nil，失败返回error错误信息
+例：屏蔽图片和字体
+	tag.HookFetchEn([]chrome.FetchPattern{{ResourceType: "Image"}, {ResourceType: "Font"}}, func(tag *chrome.Tag, req *chrome.FetchRequest) {
+		req.Fail("BlockedByClient")
+	})
+*/
+func (p *Tag) HookFetchEn(patterns []FetchPattern, handler func(tag *Tag, req *FetchRequest)) error {
+	if patterns == nil {
+		patterns = []FetchPattern{{UrlPattern: "*"}}
+	}
+	//回调在消息监听协程中读取，需加锁
+	p.taskLock.Lock()
+	p.fetchEvent = handler
+	p.taskLock.Unlock()
+	parm := make(map[string]interface{})
+	parm["patterns"] = patterns
+	_, err := p.Call("Fetch.enable", parm)
+	return err
+}
+
+/**
+禁用拦截并修改网络请求，已暂停的请求会被自动放行
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *Tag) HookFetchDis() error {
+	p.taskLock.Lock()
+	p.fetchEvent = nil
+	p.taskLock.Unlock()
+	_, err := p.Call("Fetch.disable", nil)
+	return err
+}
+
+/**
+处理被拦截的请求，回调未放行时自动放行，防止页面一直等待
+*/
+func (p *Tag) fetchHandle(handler func(tag *Tag, req *FetchRequest), req *FetchRequest) {
+	handler(p, req)
+	if !req.handled {
+		req.Continue()
+	}
+}
+
+/**
+原样继续请求
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *FetchRequest) Continue() error {
+	return p.ContinueWith(FetchContinue{})
+}
+
+/**
+修改后继续请求，可修改网址、请求模式、POST数据、协议头
+传参：
+	opts：修改内容，字段为空表示不修改
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *FetchRequest) ContinueWith(opts FetchContinue) error {
+	parm := make(map[string]interface{})
+	parm["requestId"] = p.RequestId
+	if opts.Url != "" {
+		parm["url"] = opts.Url
+	}
+	if opts.Method != "" {
+		parm["method"] = opts.Method
+	}
+	if opts.PostData != nil {
+		parm["postData"] = string(big.EnCodeBase64(opts.PostData))
+	}
+	if opts.Headers != nil {
+		parm["headers"] = headerEntries(opts.Headers)
+	}
+	p.handled = true
+	_, err := p.tag.Call("Fetch.continueRequest", parm)
+	return err
+}
+
+/**
+伪造响应，请求不会发送到服务器
+传参：
+	status：响应状态码，如：200
+	headers：响应协议头，可空
+	body：响应内容，可空
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *FetchRequest) Fulfill(status int, headers map[string]string, body []byte) error {
+	parm := make(map[string]interface{})
+	parm["requestId"] = p.RequestId
+	parm["responseCode"] = status
+	parm["responseHeaders"] = headerEntries(headers)
+	if body != nil {
+		parm["body"] = string(big.EnCodeBase64(body))
+	}
+	p.handled = true
+	_, err := p.tag.Call("Fetch.fulfillRequest", parm)
+	return err
+}
+
+/**
+使请求失败
+传参：
+	reason：失败原因，可选值：Failed, Aborted, TimedOut, AccessDenied, ConnectionClosed, ConnectionReset, ConnectionRefused,
+			ConnectionAborted, ConnectionFailed, NameNotResolved, InternetDisconnected, AddressUnreachable, BlockedByClient, BlockedByResponse
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *FetchRequest) Fail(reason string) error {
+	parm := make(map[string]interface{})
+	parm["requestId"] = p.RequestId
+	parm["errorReason"] = reason
+	p.handled = true
+	_, err := p.tag.Call("Fetch.failRequest", parm)
+	return err
+}
+
+/**
+取响应内容，仅在响应阶段（RequestStage为Response）拦截的请求可用
+返回：
+	响应内容，失败时error返回具体信息
+*/
+func (p *FetchRequest) GetBody() ([]byte, error) {
+	parm := make(map[string]interface{})
+	parm["requestId"] = p.RequestId
+	res, err := p.tag.Call("Fetch.getResponseBody", parm)
+	if err != nil {
+		return nil, err
+	}
+	var rsp struct {
+		Result struct {
+			Body          string `json:"body"`
+			Base64Encoded bool   `json:"base64Encoded"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return nil, err
+	}
+	if rsp.Result.Base64Encoded {
+		return big.EnCodeBase64Un([]byte(rsp.Result.Body))
+	}
+	return []byte(rsp.Result.Body), nil
+}
+
+/**
+取响应协议头，不区分大小写，仅在响应阶段拦截的请求可用
+传参：
+	name：协议头名称
+返回：
+	协议头值，不存在返回空文本
+*/
+func (p *FetchRequest) GetResponseHeader(name string) string {
+	for _, v := range p.ResponseHeaders {
+		if strings.EqualFold(v.Name, name) {
+			return v.Value
+		}
+	}
+	return ""
+}
+
+/**
+将map形式的协议头转为协议头条目切片，按名称排序保证顺序稳定
+*/
+func headerEntries(headers map[string]string) []HeaderEntry {
+	res := make([]HeaderEntry, 0, len(headers))
+	for k, v := range headers {
+		res = append(res, HeaderEntry{Name: k, Value: v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
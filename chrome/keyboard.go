@@ -0,0 +1,390 @@
+package chrome
+
+import (
+	"b/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//功能键对应的modifiers掩码
+var modifierBits = map[string]int{"Alt": 1, "Control": 2, "Meta": 4, "Shift": 8}
+
+//键盘按键定义
+type keyDefinition struct {
+	key       string //按键值，对应网页中event.key，如：a、Enter
+	shiftKey  string //按住Shift时的按键值，如：A，没有时为空
+	code      string //物理按键，对应网页中event.code，如：KeyA
+	keyCode   int    //Windows虚拟键代码，对应网页中event.keyCode
+	text      string //按下时输入的文本，功能键为空
+	shiftText string //按住Shift按下时输入的文本
+	location  int    //按键位置：0=标准，1=左侧，2=右侧，3=小键盘
+}
+
+//美式键盘布局，key是按键值、按住Shift时的按键值或物理按键
+var keyboardLayout = buildKeyboardLayout()
+
+//美式键盘字符所在的行，用于模拟输错时选取相邻按键
+var keyboardRows = []string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"}
+
+/**
+生成美式键盘布局
+*/
+func buildKeyboardLayout() map[string]*keyDefinition {
+	defs := []*keyDefinition{
+		{key: " ", code: "Space", keyCode: 32, text: " "},
+		{key: "Enter", code: "Enter", keyCode: 13, text: "\r"},
+		{key: "Tab", code: "Tab", keyCode: 9},
+		{key: "Backspace", code: "Backspace", keyCode: 8},
+		{key: "Shift", code: "ShiftLeft", keyCode: 16, location: 1},
+		{key: "Control", code: "ControlLeft", keyCode: 17, location: 1},
+		{key: "Alt", code: "AltLeft", keyCode: 18, location: 1},
+		{key: "Meta", code: "MetaLeft", keyCode: 91, location: 1},
+		{key: "Escape", code: "Escape", keyCode: 27},
+		{key: "Delete", code: "Delete", keyCode: 46},
+		{key: "Insert", code: "Insert", keyCode: 45},
+		{key: "Home", code: "Home", keyCode: 36},
+		{key: "End", code: "End", keyCode: 35},
+		{key: "PageUp", code: "PageUp", keyCode: 33},
+		{key: "PageDown", code: "PageDown", keyCode: 34},
+		{key: "ArrowLeft", code: "ArrowLeft", keyCode: 37},
+		{key: "ArrowUp", code: "ArrowUp", keyCode: 38},
+		{key: "ArrowRight", code: "ArrowRight", keyCode: 39},
+		{key: "ArrowDown", code: "ArrowDown", keyCode: 40},
+		{key: "CapsLock", code: "CapsLock", keyCode: 20},
+		{key: "NumLock", code: "NumLock", keyCode: 144},
+		{key: "ScrollLock", code: "ScrollLock", keyCode: 145},
+		{key: "Pause", code: "Pause", keyCode: 19},
+		{key: "PrintScreen", code: "PrintScreen", keyCode: 44},
+		{key: "ContextMenu", code: "ContextMenu", keyCode: 93},
+		{key: "`", shiftKey: "~", code: "Backquote", keyCode: 192},
+		{key: "-", shiftKey: "_", code: "Minus", keyCode: 189},
+		{key: "=", shiftKey: "+", code: "Equal", keyCode: 187},
+		{key: "[", shiftKey: "{", code: "BracketLeft", keyCode: 219},
+		{key: "]", shiftKey: "}", code: "BracketRight", keyCode: 221},
+		{key: "\\", shiftKey: "|", code: "Backslash", keyCode: 220},
+		{key: ";", shiftKey: ":", code: "Semicolon", keyCode: 186},
+		{key: "'", shiftKey: "\"", code: "Quote", keyCode: 222},
+		{key: ",", shiftKey: "<", code: "Comma", keyCode: 188},
+		{key: ".", shiftKey: ">", code: "Period", keyCode: 190},
+		{key: "/", shiftKey: "?", code: "Slash", keyCode: 191},
+	}
+	//数字键，按住Shift时为符号
+	digitShift := ")!@#$%^&*("
+	for i := 0; i < 10; i++ {
+		d := string(rune('0' + i))
+		defs = append(defs, &keyDefinition{key: d, shiftKey: digitShift[i : i+1], code: "Digit" + d, keyCode: '0' + i})
+	}
+	//F1~F12
+	for i := 1; i <= 12; i++ {
+		f := "F" + strconv.Itoa(i)
+		defs = append(defs, &keyDefinition{key: f, code: f, keyCode: 111 + i})
+	}
+	//字母键，按住Shift时为大写
+	for c := 'a'; c <= 'z'; c++ {
+		upper := strings.ToUpper(string(c))
+		defs = append(defs, &keyDefinition{key: string(c), shiftKey: upper, code: "Key" + upper, keyCode: int('A' + c - 'a')})
+	}
+	layout := make(map[string]*keyDefinition)
+	for _, def := range defs {
+		//可输入字符的按键，文本与按键值相同
+		if def.text == "" && len(def.key) == 1 {
+			def.text = def.key
+			def.shiftText = def.shiftKey
+		}
+		layout[def.key] = def
+		layout[def.code] = def
+		if def.shiftKey != "" {
+			layout[def.shiftKey] = def
+		}
+	}
+	//换行符按回车键处理
+	layout["\n"] = layout["Enter"]
+	layout["\r"] = layout["Enter"]
+	//常用别名
+	for alias, key := range map[string]string{
+		"Ctrl": "Control", "Cmd": "Meta", "Command": "Meta", "Win": "Meta", "Option": "Alt",
+		"Esc": "Escape", "Del": "Delete", "Ins": "Insert", "Return": "Enter", "Space": " ",
+		"Up": "ArrowUp", "Down": "ArrowDown", "Left": "ArrowLeft", "Right": "ArrowRight",
+	} {
+		layout[alias] = layout[key]
+	}
+	return layout
+}
+
+/**
+查找按键定义
+传参：
+	name：按键值、按住Shift时的按键值或物理按键，如：a、A、KeyA、Enter
+返回：
+	def：按键定义
+	shift：是否需要按住Shift才能输入
+	ok：是否找到
+*/
+func lookupKey(name string) (def *keyDefinition, shift bool, ok bool) {
+	def, ok = keyboardLayout[name]
+	if !ok {
+		return nil, false, false
+	}
+	return def, def.shiftKey == name && def.key != name, true
+}
+
+/**
+按虚拟键代码查找按键定义，找不到时返回nil
+*/
+func lookupKeyCode(keyCode int) *keyDefinition {
+	for _, def := range keyboardLayout {
+		if def.keyCode == keyCode {
+			return def
+		}
+	}
+	return nil
+}
+
+/**
+发送带完整按键信息的键盘事件
+传参：
+	typ：事件类型，可选值：keyDown、keyUp，keyDown时按键不产生文本则自动改为rawKeyDown
+	def：按键定义
+	shift：是否为按住Shift时的按键值和文本
+	modifiers：功能键，可选值：Alt = 1，Ctrl = 2，Meta/Command = 4，Shift = 8，可相加
+*/
+func (p *Tag) dispatchKey(typ string, def *keyDefinition, shift bool, modifiers int) error {
+	key, text := def.key, def.text
+	if shift && def.shiftKey != "" {
+		key, text = def.shiftKey, def.shiftText
+	}
+	if typ == "keyUp" || modifiers&(1|2|4) != 0 {
+		//按住Alt、Ctrl、Meta时为快捷键，不输入文本
+		text = ""
+	}
+	if typ == "keyDown" && text == "" {
+		typ = "rawKeyDown"
+	}
+	parm := make(map[string]interface{})
+	parm["type"] = typ
+	parm["modifiers"] = modifiers
+	parm["key"] = key
+	parm["code"] = def.code
+	parm["windowsVirtualKeyCode"] = def.keyCode
+	parm["nativeVirtualKeyCode"] = def.keyCode
+	parm["text"] = text
+	parm["unmodifiedText"] = text
+	parm["location"] = def.location
+	parm["isKeypad"] = def.location == 3
+	_, err := p.Call("Input.dispatchKeyEvent", parm)
+	return err
+}
+
+/**
+按下并松开一个按键，需要Shift的字符会先按下Shift
+传参：
+	def：按键定义
+	shift：是否需要按住Shift
+	hold：按键按住的时间，单位毫秒
+*/
+func (p *Tag) typeKey(def *keyDefinition, shift bool, hold int) error {
+	//叠加通过KeyDown按住的功能键
+	modifiers := p.modifiers
+	pressShift := shift && modifiers&8 == 0
+	if pressShift {
+		modifiers |= 8
+		if err := p.dispatchKey("keyDown", keyboardLayout["Shift"], false, modifiers); err != nil {
+			return err
+		}
+	}
+	if err := p.dispatchKey("keyDown", def, shift, modifiers); err != nil {
+		return err
+	}
+	time.Sleep(time.Duration(hold) * time.Millisecond)
+	if err := p.dispatchKey("keyUp", def, shift, modifiers); err != nil {
+		return err
+	}
+	if pressShift {
+		return p.dispatchKey("keyUp", keyboardLayout["Shift"], false, p.modifiers)
+	}
+	return nil
+}
+
+/**
+取键盘上与字符相邻的字符，用于模拟输错，字符不在键盘上时返回false
+*/
+func typoRune(r rune) (string, bool) {
+	s := strings.ToLower(string(r))
+	for _, row := range keyboardRows {
+		i := strings.Index(row, s)
+		if i == -1 {
+			continue
+		}
+		j := i + 1
+		if i == len(row)-1 || (i > 0 && big.ProgRangeRand(0, 1, 0) == 0) {
+			j = i - 1
+		}
+		typo := row[j : j+1]
+		if s != string(r) {
+			//原字符为大写或Shift符号时，输错的也按住Shift
+			if def, _, ok := lookupKey(typo); ok && def.shiftKey != "" {
+				typo = def.shiftKey
+			}
+		}
+		return typo, true
+	}
+	return "", false
+}
+
+/**
+模拟真人键盘输入文本，向当前焦点元素输入，每个字符都会产生带有key、code、keyCode的按键事件，
+大写字母和需要Shift的符号会先按下Shift，键盘无法直接输入的字符（如中文）使用Input.insertText输入，
+按键之间随机延迟，可设置按一定概率输错后退格修正
+传参：
+	text：欲输入的文本，\n按回车键处理
+	opts：输入选项，传TypeOptions{}表示默认延迟且不输错
+返回：
+	成功返回nil，失败返回error错误信息
+例：
+	tag.ClickElement("#keyword", chrome.ClickOptions{})
+	tag.Type("Hello 世界", chrome.TypeOptions{TypoRate: 0.05})
+*/
+func (p *Tag) Type(text string, opts TypeOptions) error {
+	minDelay, maxDelay := opts.MinDelay, opts.MaxDelay
+	if maxDelay < 0 {
+		minDelay, maxDelay = 0, 0
+	} else if minDelay == 0 && maxDelay == 0 {
+		minDelay, maxDelay = 50, 150
+	} else if minDelay > maxDelay {
+		minDelay = maxDelay
+	}
+	runes := []rune(text)
+	for i, r := range runes {
+		if r == '\r' && i+1 < len(runes) && runes[i+1] == '\n' {
+			//\r\n只按一次回车
+			continue
+		}
+		if i > 0 {
+			big.TimeSleepRangeRand(minDelay, maxDelay, time.Millisecond)
+		}
+		def, shift, ok := lookupKey(string(r))
+		if !ok {
+			if _, err := p.Call("Input.insertText", map[string]interface{}{"text": string(r)}); err != nil {
+				return err
+			}
+			continue
+		}
+		if opts.TypoRate > 0 && big.ProgRangeRand(0, 9999, 0) < int(opts.TypoRate*10000) {
+			if typo, ok := typoRune(r); ok {
+				typoDef, typoShift, _ := lookupKey(typo)
+				if err := p.typeKey(typoDef, typoShift, big.ProgRangeRand(minDelay/3, maxDelay/3, 0)); err != nil {
+					return err
+				}
+				//发现输错，停顿后退格
+				big.TimeSleepRangeRand(minDelay*2, maxDelay*3, time.Millisecond)
+				if err := p.typeKey(keyboardLayout["Backspace"], false, big.ProgRangeRand(minDelay/3, maxDelay/3, 0)); err != nil {
+					return err
+				}
+				big.TimeSleepRangeRand(minDelay, maxDelay, time.Millisecond)
+			}
+		}
+		if err := p.typeKey(def, shift, big.ProgRangeRand(minDelay/3, maxDelay/3, 0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+按下按键不松开，按下的功能键（Shift、Control、Alt、Meta）会作用于后续KeyDown、Press、Type等方法产生的键盘事件，直到调用KeyUp松开
+传参：
+	key：按键名，如：a、A、Enter、Tab、ArrowDown、F5、Escape、Shift、Control，也可用物理按键名如KeyA、Digit1，
+		支持别名：Ctrl、Cmd、Win、Option、Esc、Del、Ins、Return、Space、Up、Down、Left、Right
+返回：
+	成功返回nil，按键名无法识别时返回ErrInvalidParam
+*/
+func (p *Tag) KeyDown(key string) error {
+	def, shift, ok := lookupKey(key)
+	if !ok {
+		return ErrInvalidParam
+	}
+	p.modifiers |= modifierBits[def.key]
+	return p.dispatchKey("keyDown", def, shift || p.modifiers&8 != 0, p.modifiers)
+}
+
+/**
+松开按键
+传参：
+	key：按键名，同KeyDown
+返回：
+	成功返回nil，按键名无法识别时返回ErrInvalidParam
+*/
+func (p *Tag) KeyUp(key string) error {
+	def, shift, ok := lookupKey(key)
+	if !ok {
+		return ErrInvalidParam
+	}
+	p.modifiers &^= modifierBits[def.key]
+	return p.dispatchKey("keyUp", def, shift || p.modifiers&8 != 0, p.modifiers)
+}
+
+/**
+按下组合键，按顺序按下全部按键后再按相反顺序松开
+传参：
+	keys：组合键，多个按键用+连接，如：Control+Shift+A、Ctrl+C、Enter、Shift+Tab、Control++，按键名同KeyDown
+返回：
+	成功返回nil，按键名无法识别或同一个键重复出现时返回ErrInvalidParam，此时不会按下任何按键
+*/
+func (p *Tag) Press(keys string) error {
+	names, err := parseAccelerator(keys)
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		if err = p.KeyDown(name); err != nil {
+			//按下失败时松开已按下的按键，避免功能键一直处于按住状态
+			for j := i - 1; j >= 0; j-- {
+				p.KeyUp(names[j])
+			}
+			return err
+		}
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		if err = p.KeyUp(names[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+解析组合键文本，如：Control+Shift+A 解析为 [Control Shift A]，Control++ 解析为 [Control +]
+*/
+func parseAccelerator(keys string) ([]string, error) {
+	names := make([]string, 0)
+	for keys != "" {
+		i := strings.Index(keys[1:], "+")
+		if i == -1 {
+			names = append(names, keys)
+			break
+		}
+		names = append(names, keys[:i+1])
+		keys = keys[i+2:]
+		if keys == "" {
+			//以+结尾，如Control+
+			return nil, ErrInvalidParam
+		}
+	}
+	if len(names) == 0 {
+		return nil, ErrInvalidParam
+	}
+	pressed := make(map[*keyDefinition]bool)
+	for _, name := range names {
+		def, _, ok := lookupKey(name)
+		if !ok {
+			return nil, ErrInvalidParam
+		}
+		//同一个键重复出现，如Control+Ctrl+A、a+A
+		if pressed[def] {
+			return nil, ErrInvalidParam
+		}
+		pressed[def] = true
+	}
+	return names, nil
+}
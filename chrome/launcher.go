@@ -0,0 +1,164 @@
+package chrome
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//浏览器开始监听调试端口时输出到stderr的提示
+const devToolsListening = "DevTools listening on "
+
+//由OpenBrowser启动的浏览器进程，CloseBrowser时用于结束进程
+type browserProcess struct {
+	cmd      *exec.Cmd
+	exited   chan struct{} //进程退出后关闭
+	endpoint chan string   //从stderr读到的调试地址，如：ws://127.0.0.1:9222/devtools/browser/...
+	err      error         //进程退出时的错误信息，exited关闭后才可读取
+}
+
+/**
+直接启动浏览器进程，不经过命令行解释器
+传参：
+	path：浏览器启动文件路径
+	args：启动参数
+返回：
+	浏览器进程，失败时error返回具体信息
+*/
+func startBrowser(path string, args []string) (*browserProcess, error) {
+	cmd := exec.Command(path, args...)
+	setProcessGroup(cmd)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	proc := &browserProcess{cmd: cmd, exited: make(chan struct{}), endpoint: make(chan string, 1)}
+	go func() {
+		//持续读取stderr，防止管道写满导致浏览器阻塞，读完后回收进程，防止产生僵尸进程
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if i := strings.Index(line, devToolsListening); i >= 0 {
+				select {
+				case proc.endpoint <- strings.TrimSpace(line[i+len(devToolsListening):]):
+				default:
+				}
+			}
+		}
+		ioutil.ReadAll(stderr)
+		proc.err = cmd.Wait()
+		close(proc.exited)
+	}()
+	return proc, nil
+}
+
+/**
+等待浏览器开始监听调试端口并返回实际端口，优先从stderr读取调试地址，
+读不到时（如Windows下部分浏览器不输出stderr）读取数据目录中浏览器写入的DevToolsActivePort文件
+传参：
+	ctx：上下文，用于控制超时
+	dataDir：浏览器数据目录，为空时只从stderr读取
+返回：
+	调试端口，进程退出或超时时error返回具体信息
+*/
+func (p *browserProcess) waitPort(ctx context.Context, dataDir string) (int, error) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case ws := <-p.endpoint:
+			u, err := url.Parse(ws)
+			if err != nil {
+				return 0, err
+			}
+			return strconv.Atoi(u.Port())
+		case <-p.exited:
+			return 0, errors.New("浏览器进程已退出，请检查浏览器路径和启动参数，或是否已有浏览器使用同一数据目录")
+		case <-ctx.Done():
+			return 0, errors.New("打开浏览器超时")
+		case <-ticker.C:
+			if dataDir == "" {
+				continue
+			}
+			//文件格式：第一行为端口，第二行为浏览器调试路径
+			res, err := ioutil.ReadFile(filepath.Join(dataDir, "DevToolsActivePort"))
+			if err != nil {
+				continue
+			}
+			lines := strings.Split(string(res), "\n")
+			if port, err := strconv.Atoi(strings.TrimSpace(lines[0])); err == nil && port > 0 {
+				return port, nil
+			}
+		}
+	}
+}
+
+/**
+端口是否已被监听，直接尝试连接，不依赖netstat、lsof等外部命令
+*/
+func portInUse(ip string, port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+/**
+进程是否已退出
+*/
+func (p *browserProcess) isExited() bool {
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+/**
+等待进程退出，超时未退出则强制结束进程及其子进程
+传参：
+	timeout：等待正常退出的时间
+*/
+func (p *browserProcess) stop(timeout time.Duration) {
+	select {
+	case <-p.exited:
+		return
+	case <-time.After(timeout):
+	}
+	killProcess(p.cmd)
+	select {
+	case <-p.exited:
+	case <-time.After(timeout):
+	}
+}
+
+/**
+返回候选路径中第一个存在的浏览器，候选项不是绝对路径时在PATH环境变量中查找
+*/
+func firstBrowser(candidates []string) string {
+	for _, v := range candidates {
+		if filepath.IsAbs(v) {
+			if info, err := os.Stat(v); err == nil && !info.IsDir() {
+				return v
+			}
+		} else if path, err := exec.LookPath(v); err == nil {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package chrome
+
+import (
+	"os"
+	"path/filepath"
+)
+
+/**
+查找本机安装的Chrome内核浏览器，依次查找Chrome、Chromium、Edge、Brave，先查应用程序目录再查PATH
+返回：
+	浏览器启动文件路径，未找到返回空文本
+*/
+func findBrowser() string {
+	apps := []string{
+		"Google Chrome.app/Contents/MacOS/Google Chrome",
+		"Chromium.app/Contents/MacOS/Chromium",
+		"Microsoft Edge.app/Contents/MacOS/Microsoft Edge",
+		"Brave Browser.app/Contents/MacOS/Brave Browser",
+	}
+	dirs := []string{"/Applications"}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, "Applications"))
+	}
+	candidates := make([]string, 0)
+	for _, dir := range dirs {
+		for _, app := range apps {
+			candidates = append(candidates, filepath.Join(dir, app))
+		}
+	}
+	return firstBrowser(append(candidates, "google-chrome", "chromium"))
+}
@@ -0,0 +1,26 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package chrome
+
+/**
+查找本机安装的Chrome内核浏览器，依次查找Chrome、Chromium、Edge、Brave，先查PATH再查默认安装目录
+返回：
+	浏览器启动文件路径，未找到返回空文本
+*/
+func findBrowser() string {
+	return firstBrowser([]string{
+		"google-chrome",
+		"google-chrome-stable",
+		"chromium",
+		"chromium-browser",
+		"microsoft-edge",
+		"microsoft-edge-stable",
+		"brave-browser",
+		"brave",
+		"/opt/google/chrome/chrome",
+		"/snap/bin/chromium",
+		"/opt/microsoft/msedge/msedge",
+		"/opt/brave.com/brave/brave",
+	})
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+// +build !windows
+
+package chrome
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+/**
+让浏览器在独立的进程组中运行，结束时可一并结束全部子进程
+*/
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+/**
+强制结束浏览器进程组，包括渲染、GPU等子进程
+*/
+func killProcess(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,53 @@
+//go:build windows
+// +build windows
+
+package chrome
+
+import (
+	"b/windbig"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+/**
+查找本机安装的Chrome内核浏览器，依次查找Chrome、Edge、Brave、Chromium，先查注册表再查默认安装目录
+返回：
+	浏览器启动文件路径，未找到返回空文本
+*/
+func findBrowser() string {
+	for _, name := range []string{"chrome.exe", "msedge.exe", "brave.exe"} {
+		if path := windbig.ProgGetInstallDir(name); path != "" {
+			return path
+		}
+	}
+	candidates := make([]string, 0)
+	for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "LOCALAPPDATA"} {
+		dir := os.Getenv(env)
+		if dir == "" {
+			continue
+		}
+		candidates = append(candidates,
+			filepath.Join(dir, "Google", "Chrome", "Application", "chrome.exe"),
+			filepath.Join(dir, "Microsoft", "Edge", "Application", "msedge.exe"),
+			filepath.Join(dir, "BraveSoftware", "Brave-Browser", "Application", "brave.exe"),
+			filepath.Join(dir, "Chromium", "Application", "chrome.exe"),
+		)
+	}
+	return firstBrowser(append(candidates, "chrome.exe", "msedge.exe"))
+}
+
+/**
+Windows下无需设置进程组
+*/
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+/**
+强制结束浏览器进程，主进程结束后渲染等子进程会自动退出
+*/
+func killProcess(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
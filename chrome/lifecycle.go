@@ -0,0 +1,210 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+//生命周期事件名，用于WaitLifecycle
+const (
+	LifecycleDOMContentLoaded  = "DOMContentLoaded"  //DOM解析完成
+	LifecycleLoad              = "load"              //页面及其资源加载完成
+	LifecycleNetworkAlmostIdle = "networkAlmostIdle" //网络基本空闲，500毫秒内进行中的请求不超过2个
+	LifecycleNetworkIdle       = "networkIdle"       //网络完全空闲，500毫秒内没有进行中的请求
+)
+
+//页面生命周期跟踪，按框架记录当前文档的loaderId和已触发的生命周期事件，事件由Page.lifecycleEvent更新
+type lifecycle struct {
+	lock    sync.Mutex
+	frames  map[string]*frameLifecycle //框架集合，key是frameId
+	changed chan struct{}              //状态变化通知，每次变化时关闭并替换为新通道
+}
+
+//单个框架的生命周期
+type frameLifecycle struct {
+	loaderId string          //当前文档的loaderId，每次跳转都会变化
+	events   map[string]bool //当前文档已触发的生命周期事件
+	replaced map[string]bool //已被后续跳转替换的loaderId，用于处理重定向
+}
+
+/**
+记录框架触发的生命周期事件，loaderId变化表示框架跳转到了新文档
+*/
+func (l *lifecycle) update(frameId string, loaderId string, name string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.frames == nil {
+		l.frames = make(map[string]*frameLifecycle)
+	}
+	f := l.frames[frameId]
+	if f == nil {
+		f = &frameLifecycle{replaced: make(map[string]bool)}
+		l.frames[frameId] = f
+	}
+	if f.replaced[loaderId] {
+		//旧文档迟到的事件
+		return
+	}
+	if f.loaderId != loaderId {
+		if f.loaderId != "" {
+			f.replaced[f.loaderId] = true
+		}
+		f.loaderId = loaderId
+		f.events = make(map[string]bool)
+	} else if name == "init" {
+		f.events = make(map[string]bool)
+	}
+	f.events[name] = true
+	l.broadcast()
+}
+
+/**
+移除框架，框架被移除时调用
+*/
+func (l *lifecycle) remove(frameId string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.frames, frameId)
+	l.broadcast()
+}
+
+/**
+清空全部框架，重新连接时调用，开启生命周期事件后浏览器会重新推送当前状态
+*/
+func (l *lifecycle) reset() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.frames = nil
+	l.broadcast()
+}
+
+/**
+通知等待者状态已变化，调用前需已加锁
+*/
+func (l *lifecycle) broadcast() {
+	if l.changed != nil {
+		close(l.changed)
+		l.changed = nil
+	}
+}
+
+/**
+取框架当前文档的loaderId
+*/
+func (l *lifecycle) loaderId(frameId string) string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if f := l.frames[frameId]; f != nil {
+		return f.loaderId
+	}
+	return ""
+}
+
+/**
+等待框架满足条件
+传参：
+	ctx：上下文，用于控制超时和取消
+	done：连接断开通知通道
+	frameId：框架id
+	cond：条件，在锁内执行，返回true表示满足
+返回：
+	满足条件返回nil，超时或取消时error返回ctx.Err()，连接断开时返回ErrDisconnected
+*/
+func (l *lifecycle) wait(ctx context.Context, done <-chan struct{}, frameId string, cond func(f *frameLifecycle) bool) error {
+	for {
+		l.lock.Lock()
+		if f := l.frames[frameId]; f != nil && cond(f) {
+			l.lock.Unlock()
+			return nil
+		}
+		if l.changed == nil {
+			l.changed = make(chan struct{})
+		}
+		changed := l.changed
+		l.lock.Unlock()
+		select {
+		case <-changed:
+		case <-done:
+			return ErrDisconnected
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+/**
+框架是否属于本页面，包括主框架和已加载的子框架
+*/
+func (l *lifecycle) hasFrame(frameId string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.frames[frameId] != nil
+}
+
+/**
+处理生命周期事件
+*/
+func (l *lifecycle) onEvent(params []byte) {
+	var ev struct {
+		FrameId  string `json:"frameId"`
+		LoaderId string `json:"loaderId"`
+		Name     string `json:"name"`
+	}
+	if json.Unmarshal(params, &ev) == nil && ev.FrameId != "" {
+		l.update(ev.FrameId, ev.LoaderId, ev.Name)
+	}
+}
+
+/**
+等待主框架当前文档触发指定的生命周期事件，当前文档已触发过该事件时立即返回
+注意：点击链接等操作触发的跳转，在新文档开始加载前当前文档仍是旧文档，此时请使用WaitForNavigation
+传参：
+	ctx：上下文，用于控制超时和取消
+	event：生命周期事件名，可选值：LifecycleDOMContentLoaded、LifecycleLoad、LifecycleNetworkAlmostIdle、LifecycleNetworkIdle等
+返回：
+	满足条件返回nil，超时或取消时error返回ctx.Err()，连接断开时返回ErrDisconnected
+*/
+func (p *Tag) WaitLifecycle(ctx context.Context, event string) error {
+	done, err := p.sessionDone()
+	if err != nil {
+		return err
+	}
+	return p.lifecycle.wait(ctx, done, p.Id, func(f *frameLifecycle) bool {
+		return f.events[event]
+	})
+}
+
+/**
+等待主框架中loaderId对应的文档触发指定的生命周期事件，该文档被重定向替换时等待新文档
+传参：
+	ctx：上下文，用于控制超时和取消
+	loaderId：Page.navigate返回的loaderId
+	event：生命周期事件名
+*/
+func (p *Tag) waitLoader(ctx context.Context, loaderId string, event string) error {
+	done, err := p.sessionDone()
+	if err != nil {
+		return err
+	}
+	return p.lifecycle.wait(ctx, done, p.Id, func(f *frameLifecycle) bool {
+		return (f.loaderId == loaderId || f.replaced[loaderId]) && f.events[event]
+	})
+}
+
+/**
+等待主框架中替换了oldLoaderId的新文档触发指定的生命周期事件，用于刷新页面
+传参：
+	ctx：上下文，用于控制超时和取消
+	oldLoaderId：刷新前的loaderId
+	event：生命周期事件名
+*/
+func (p *Tag) waitReplaced(ctx context.Context, oldLoaderId string, event string) error {
+	done, err := p.sessionDone()
+	if err != nil {
+		return err
+	}
+	return p.lifecycle.wait(ctx, done, p.Id, func(f *frameLifecycle) bool {
+		return f.loaderId != oldLoaderId && f.events[event]
+	})
+}
@@ -0,0 +1,309 @@
+package chrome
+
+import (
+	"b/big"
+	"math"
+	"time"
+)
+
+//鼠标轨迹生成器，根据起点和终点生成途经的轨迹点，可自行实现以定制轨迹
+type Trajectory interface {
+	//生成轨迹点，不含起点，最后一个点为终点
+	Points(fromX, fromY, toX, toY int) []TrackPoint
+}
+
+//速度曲线，将均匀的时间进度t（0~1）映射为位移进度（0~1），轨迹点按相同时间间隔移动时，位移进度变化越快鼠标移动越快
+type VelocityProfile func(t float64) float64
+
+//匀速
+func VelocityLinear(t float64) float64 {
+	return t
+}
+
+//先加速后减速，按最小加加速度曲线计算，最接近人手移动鼠标的速度变化
+func VelocityEaseInOut(t float64) float64 {
+	return t * t * t * (10 - 15*t + 6*t*t)
+}
+
+//快速启动后逐渐减速
+func VelocityEaseOut(t float64) float64 {
+	return 1 - (1-t)*(1-t)*(1-t)
+}
+
+//默认鼠标轨迹，InputMouseMove和未指定轨迹的ClickElement使用，可替换为其他轨迹
+var DefaultTrack Trajectory = &OvershootTrack{}
+
+//直线轨迹
+type LinearTrack struct {
+	Steps    int             //轨迹点数，传0表示按距离自动计算
+	MinDelay int             //每步最小延迟，单位毫秒，与MaxDelay均为0时默认5~15毫秒
+	MaxDelay int             //每步最大延迟，单位毫秒，小于0表示不延迟
+	Velocity VelocityProfile //速度曲线，传nil表示匀速
+}
+
+//贝塞尔曲线轨迹，路径随机弯曲并带有细微抖动
+type BezierTrack struct {
+	Steps    int             //轨迹点数，传0表示按距离自动计算
+	Spread   float64         //弯曲程度，控制点偏离直线的最大距离与移动距离之比，传0表示默认0.25，小于0表示不弯曲
+	Jitter   float64         //抖动幅度，单位像素，传0表示默认1像素，小于0表示不抖动
+	MinDelay int             //每步最小延迟，单位毫秒，与MaxDelay均为0时默认5~15毫秒
+	MaxDelay int             //每步最大延迟，单位毫秒，小于0表示不延迟
+	Velocity VelocityProfile //速度曲线，传nil表示VelocityEaseInOut
+}
+
+//冲过终点再修正的轨迹，先沿主轨迹移动到终点附近稍远处，停顿后再移回终点，移动距离较短时不冲过
+type OvershootTrack struct {
+	Track    Trajectory //主轨迹，传nil表示BezierTrack{}
+	Distance int        //冲过终点的最大距离，单位像素，传0表示按移动距离自动计算
+	Pause    int        //冲过后停顿的最大时间，单位毫秒，传0表示默认150毫秒
+}
+
+/**
+生成直线轨迹点
+*/
+func (p *LinearTrack) Points(fromX, fromY, toX, toY int) []TrackPoint {
+	velocity := p.Velocity
+	if velocity == nil {
+		velocity = VelocityLinear
+	}
+	steps := trackSteps(p.Steps, fromX, fromY, toX, toY)
+	minDelay, maxDelay := trackDelay(p.MinDelay, p.MaxDelay)
+	points := make([]TrackPoint, 0, steps)
+	for i := 1; i <= steps; i++ {
+		s := velocity(float64(i) / float64(steps))
+		points = append(points, TrackPoint{
+			X:        int(math.Round(float64(fromX) + float64(toX-fromX)*s)),
+			Y:        int(math.Round(float64(fromY) + float64(toY-fromY)*s)),
+			MinDelay: minDelay,
+			MaxDelay: maxDelay,
+		})
+	}
+	return points
+}
+
+/**
+生成贝塞尔曲线轨迹点，两个控制点分别位于路径前段和后段，随机偏向直线两侧
+*/
+func (p *BezierTrack) Points(fromX, fromY, toX, toY int) []TrackPoint {
+	velocity := p.Velocity
+	if velocity == nil {
+		velocity = VelocityEaseInOut
+	}
+	spread := p.Spread
+	if spread == 0 {
+		spread = 0.25
+	} else if spread < 0 {
+		spread = 0
+	}
+	jitter := p.Jitter
+	if jitter == 0 {
+		jitter = 1
+	} else if jitter < 0 {
+		jitter = 0
+	}
+	steps := trackSteps(p.Steps, fromX, fromY, toX, toY)
+	minDelay, maxDelay := trackDelay(p.MinDelay, p.MaxDelay)
+	x0, y0 := float64(fromX), float64(fromY)
+	x3, y3 := float64(toX), float64(toY)
+	dx, dy := x3-x0, y3-y0
+	//控制点 = 起点 + 沿路径方向的分量 + 垂直路径方向的随机偏移，两个分量的x、y共用同一比例，不弯曲时控制点落在直线上
+	a1, s1 := randFloat(0.2, 0.4), randFloat(-spread, spread)
+	a2, s2 := randFloat(0.6, 0.8), randFloat(-spread, spread)
+	x1 := x0 + dx*a1 - dy*s1
+	y1 := y0 + dy*a1 + dx*s1
+	x2 := x0 + dx*a2 - dy*s2
+	y2 := y0 + dy*a2 + dx*s2
+	points := make([]TrackPoint, 0, steps)
+	for i := 1; i <= steps; i++ {
+		t := velocity(float64(i) / float64(steps))
+		u := 1 - t
+		x := u*u*u*x0 + 3*u*u*t*x1 + 3*u*t*t*x2 + t*t*t*x3
+		y := u*u*u*y0 + 3*u*u*t*y1 + 3*u*t*t*y2 + t*t*t*y3
+		if i < steps {
+			//抖动在路径中段最大，两端逐渐减小，保证准确落在终点
+			k := math.Sin(math.Pi * float64(i) / float64(steps))
+			x += randFloat(-jitter, jitter) * k
+			y += randFloat(-jitter, jitter) * k
+		}
+		points = append(points, TrackPoint{X: int(math.Round(x)), Y: int(math.Round(y)), MinDelay: minDelay, MaxDelay: maxDelay})
+	}
+	return points
+}
+
+/**
+生成冲过终点再修正的轨迹点
+*/
+func (p *OvershootTrack) Points(fromX, fromY, toX, toY int) []TrackPoint {
+	track := p.Track
+	if track == nil {
+		track = &BezierTrack{}
+	}
+	dist := math.Hypot(float64(toX-fromX), float64(toY-fromY))
+	maxOver := float64(p.Distance)
+	if maxOver <= 0 {
+		maxOver = math.Min(math.Max(dist*0.1, 5), 40)
+	}
+	if dist < 50 || maxOver < 2 {
+		return track.Points(fromX, fromY, toX, toY)
+	}
+	pause := p.Pause
+	if pause <= 0 {
+		pause = 150
+	}
+	//沿移动方向冲过终点，并带少量侧向偏移
+	over := randFloat(maxOver/2, maxOver)
+	ux, uy := float64(toX-fromX)/dist, float64(toY-fromY)/dist
+	side := randFloat(-over/3, over/3)
+	overX := int(math.Round(float64(toX) + ux*over - uy*side))
+	overY := int(math.Round(float64(toY) + uy*over + ux*side))
+	points := track.Points(fromX, fromY, overX, overY)
+	back := (&LinearTrack{Steps: big.ProgRangeRand(3, 6, 0), Velocity: VelocityEaseOut}).Points(overX, overY, toX, toY)
+	back[0].MinDelay += pause / 3
+	back[0].MaxDelay += pause
+	return append(points, back...)
+}
+
+/**
+计算轨迹点数，未指定时按距离计算，每10像素左右一个点
+*/
+func trackSteps(steps int, fromX, fromY, toX, toY int) int {
+	if steps > 0 {
+		return steps
+	}
+	dist := math.Hypot(float64(toX-fromX), float64(toY-fromY))
+	steps = int(dist/10) + big.ProgRangeRand(0, 4, 0)
+	if steps < 5 {
+		steps = 5
+	} else if steps > 60 {
+		steps = 60
+	}
+	return steps
+}
+
+/**
+计算每步延迟范围，均为0时默认5~15毫秒，最大延迟小于0表示不延迟，最小延迟小于0按0计算
+*/
+func trackDelay(minDelay, maxDelay int) (int, int) {
+	if maxDelay < 0 {
+		return 0, 0
+	}
+	if minDelay == 0 && maxDelay == 0 {
+		return 5, 15
+	}
+	if minDelay < 0 {
+		minDelay = 0
+	}
+	if minDelay > maxDelay {
+		minDelay = maxDelay
+	}
+	return minDelay, maxDelay
+}
+
+/**
+取范围内的随机小数
+*/
+func randFloat(min, max float64) float64 {
+	return min + (max-min)*float64(big.ProgRangeRand(0, 10000, 0))/10000
+}
+
+/**
+按指定轨迹模拟鼠标移动到指定坐标
+传参：
+	x：事件的X坐标相对于CSS像素中的主框架的视口
+	y：事件的Y坐标相对于CSS像素中的主框架视口
+	track：鼠标轨迹，可用LinearTrack、BezierTrack、OvershootTrack或自行实现Trajectory接口，传nil表示使用DefaultTrack
+返回：
+	成功返回nil，轨迹点的延迟为负数时返回ErrInvalidParam，失败返回error错误信息
+例：
+	tag.InputMouseMoveTrack(500, 300, &chrome.BezierTrack{Spread: 0.4, Velocity: chrome.VelocityEaseOut})
+*/
+func (p *Tag) InputMouseMoveTrack(x int, y int, track Trajectory) error {
+	if track == nil {
+		track = DefaultTrack
+	}
+	points := track.Points(p.px, p.py, x, y)
+	//自定义轨迹的延迟可能不合法，移动前先检查，避免移动到一半才出错
+	for _, pt := range points {
+		if pt.MinDelay < 0 || pt.MaxDelay < 0 {
+			return ErrInvalidParam
+		}
+	}
+	for _, pt := range points {
+		minDelay, maxDelay := pt.MinDelay, pt.MaxDelay
+		if minDelay > maxDelay {
+			minDelay, maxDelay = maxDelay, minDelay
+		}
+		big.TimeSleepRangeRand(minDelay, maxDelay, time.Millisecond)
+		if err := p.InputSendMouse("mouseMoved", pt.X, pt.Y, 0, "none", 0, 0, 0); err != nil {
+			return err
+		}
+		p.px, p.py = pt.X, pt.Y
+	}
+	if p.px != x || p.py != y {
+		//自定义轨迹未以终点结束时补发终点
+		if err := p.InputSendMouse("mouseMoved", x, y, 0, "none", 0, 0, 0); err != nil {
+			return err
+		}
+		p.px, p.py = x, y
+	}
+	return nil
+}
+
+/**
+用真实的鼠标事件点击元素：滚动页面使元素可见，按盒模型计算元素中心，模拟鼠标轨迹移动过去后按下并松开，
+与DomClick调用JS的element.click()不同，本方法会触发悬停、焦点等真实交互效果，不易被网页识别
+传参：
+	selector：选择器，支持CSS、XPath、文本匹配，格式见Query
+	opts：点击选项，可指定按键、次数、偏移、按键间隔、框架，传ClickOptions{}表示左键单击元素中心
+返回：
+	成功返回nil，未找到元素返回ErrNotFound，元素不可见时浏览器返回*CDPError
+*/
+func (p *Tag) ClickElement(selector string, opts ClickOptions) error {
+	el, err := p.QueryFrame(opts.Frame, selector)
+	if err != nil {
+		return err
+	}
+	defer el.Release()
+	return el.ClickWith(opts)
+}
+
+/**
+用真实的鼠标事件点击元素，opts.Frame在本方法中无效
+传参：
+	opts：点击选项，传ClickOptions{}表示左键单击元素中心
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *ElementHandle) ClickWith(opts ClickOptions) error {
+	if opts.Button == "" {
+		opts.Button = "left"
+	}
+	if opts.ClickCount <= 0 {
+		opts.ClickCount = 1
+	}
+	if err := p.ScrollIntoView(); err != nil {
+		return err
+	}
+	box, err := p.BoundingBox()
+	if err != nil {
+		return err
+	}
+	x := int(box.X + box.Width/2 + opts.OffsetX)
+	y := int(box.Y + box.Height/2 + opts.OffsetY)
+	if err = p.tag.InputMouseMoveTrack(x, y, opts.Track); err != nil {
+		return err
+	}
+	//多次点击时clickCount逐次递增，与真实双击产生的事件一致
+	for i := 1; i <= opts.ClickCount; i++ {
+		if err = p.tag.InputSendMouse("mousePressed", x, y, opts.Modifiers, opts.Button, i, 0, 0); err != nil {
+			return err
+		}
+		if opts.Delay > 0 {
+			time.Sleep(opts.Delay)
+		}
+		if err = p.tag.InputSendMouse("mouseReleased", x, y, opts.Modifiers, opts.Button, i, 0, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
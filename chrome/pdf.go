@@ -0,0 +1,177 @@
+package chrome
+
+import (
+	"b/big"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+//常用纸张规格的宽高，单位英寸
+var paperFormats = map[string][2]float64{
+	"letter":  {8.5, 11},
+	"legal":   {8.5, 14},
+	"tabloid": {11, 17},
+	"ledger":  {17, 11},
+	"a0":      {33.1, 46.8},
+	"a1":      {23.4, 33.1},
+	"a2":      {16.54, 23.4},
+	"a3":      {11.7, 16.54},
+	"a4":      {8.27, 11.7},
+	"a5":      {5.83, 8.27},
+	"a6":      {4.13, 5.83},
+}
+
+//每次从流中读取的最大字节数
+const pdfReadSize = 1 << 20
+
+/**
+将页面导出为PDF，仅无头模式（headless）的浏览器支持，PDF内容通过流分块读取，页面很大时也不会产生超大的ws消息
+传参：
+	opts：导出选项，传PdfOptions{}表示Letter纸张、无边距、不打印背景
+返回：
+	PDF文件内容，纸张规格无法识别时返回ErrInvalidParam，浏览器不支持时返回*CDPError
+例：
+	pdf, err := tag.PrintPDF(chrome.PdfOptions{Format: "A4", PrintBackground: true, MarginTop: 0.4, MarginBottom: 0.4})
+*/
+func (p *Tag) PrintPDF(opts PdfOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := p.printPDF(opts, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+/**
+将页面导出为PDF文件，边读取边写入文件，适合导出很大的页面
+传参：
+	path：保存的文件路径，文件已存在时覆盖
+	opts：导出选项，同PrintPDF
+返回：
+	成功返回nil，失败返回error错误信息，失败时不保留不完整的文件
+*/
+func (p *Tag) PrintPDFToFile(path string, opts PdfOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = p.printPDF(opts, f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+/**
+导出PDF并写入w
+*/
+func (p *Tag) printPDF(opts PdfOptions, w io.Writer) error {
+	parm := make(map[string]interface{})
+	if opts.Format != "" {
+		size, ok := paperFormats[strings.ToLower(opts.Format)]
+		if !ok {
+			return ErrInvalidParam
+		}
+		opts.PaperWidth, opts.PaperHeight = size[0], size[1]
+	}
+	if opts.PaperWidth > 0 {
+		parm["paperWidth"] = opts.PaperWidth
+	}
+	if opts.PaperHeight > 0 {
+		parm["paperHeight"] = opts.PaperHeight
+	}
+	if opts.Scale > 0 {
+		parm["scale"] = opts.Scale
+	}
+	parm["landscape"] = opts.Landscape
+	parm["marginTop"] = opts.MarginTop
+	parm["marginBottom"] = opts.MarginBottom
+	parm["marginLeft"] = opts.MarginLeft
+	parm["marginRight"] = opts.MarginRight
+	parm["pageRanges"] = opts.PageRanges
+	parm["printBackground"] = opts.PrintBackground
+	parm["displayHeaderFooter"] = opts.DisplayHeaderFooter
+	if opts.DisplayHeaderFooter {
+		//模板为空时浏览器会使用默认的页眉页脚，传空元素表示不显示
+		if opts.HeaderTemplate == "" {
+			opts.HeaderTemplate = "<span></span>"
+		}
+		if opts.FooterTemplate == "" {
+			opts.FooterTemplate = "<span></span>"
+		}
+		parm["headerTemplate"] = opts.HeaderTemplate
+		parm["footerTemplate"] = opts.FooterTemplate
+	}
+	parm["preferCSSPageSize"] = opts.PreferCSSPageSize
+	parm["transferMode"] = "ReturnAsStream"
+	res, err := p.Call("Page.printToPDF", parm)
+	if err != nil {
+		return err
+	}
+	var rsp struct {
+		Result struct {
+			Data   string `json:"data"`
+			Stream string `json:"stream"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return err
+	}
+	if rsp.Result.Stream == "" {
+		//旧版浏览器不支持流，直接返回全部内容
+		data, err := big.EnCodeBase64Un([]byte(rsp.Result.Data))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return p.readStream(rsp.Result.Stream, w)
+}
+
+/**
+分块读取IO域的流并写入w，读取完成或失败后关闭流
+传参：
+	handle：流句柄
+	w：写入目标
+*/
+func (p *Tag) readStream(handle string, w io.Writer) error {
+	defer p.Call("IO.close", map[string]interface{}{"handle": handle})
+	for {
+		parm := make(map[string]interface{})
+		parm["handle"] = handle
+		parm["size"] = pdfReadSize
+		res, err := p.Call("IO.read", parm)
+		if err != nil {
+			return err
+		}
+		var rsp struct {
+			Result struct {
+				Data          string `json:"data"`
+				Base64Encoded bool   `json:"base64Encoded"`
+				Eof           bool   `json:"eof"`
+			} `json:"result"`
+		}
+		if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+			return err
+		}
+		data := []byte(rsp.Result.Data)
+		if rsp.Result.Base64Encoded {
+			//每块数据单独编码
+			if data, err = big.EnCodeBase64Un(data); err != nil {
+				return err
+			}
+		}
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+		if rsp.Result.Eof {
+			return nil
+		}
+	}
+}
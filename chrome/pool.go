@@ -0,0 +1,356 @@
+package chrome
+
+import (
+	"b/big"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//浏览器池，启动多个浏览器并向并发的工作协程租出相互隔离的标签，线程安全的
+type BrowserPool struct {
+	opts    PoolOptions
+	lock    sync.Mutex
+	entries []*poolEntry
+	changed chan struct{} //状态变化通知，每次变化时关闭并替换为新通道
+	closed  bool          //是否已关闭，关闭后不再租出
+	stop    chan struct{} //关闭时通知健康检查协程退出
+}
+
+//浏览器池中的一个浏览器
+type poolEntry struct {
+	browser   *Browser
+	active    int  //已租出未归还的标签数
+	uses      int  //累计租出次数
+	retiring  bool //是否待替换，待替换的浏览器不再租出
+	replacing bool //是否正在替换
+}
+
+/**
+创建浏览器池并启动全部浏览器
+传参：
+	opts：浏览器池选项
+返回：
+	浏览器池，有浏览器启动失败时关闭已启动的浏览器并返回error错误信息
+例：
+	pool, err := chrome.NewBrowserPool(chrome.PoolOptions{Size: 4, MaxTabs: 8, MaxUses: 200, Incognito: true})
+	lease, err := pool.Acquire(ctx)
+	defer pool.Release(lease)
+	err = lease.Tag.TagJump("https://www.baidu.com", "", 30, "")
+*/
+func NewBrowserPool(opts PoolOptions) (*BrowserPool, error) {
+	if opts.Size <= 0 {
+		opts.Size = 1
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = 30 * time.Second
+	}
+	if opts.New == nil {
+		opts.New = func() *Browser {
+			return &Browser{Hide: true}
+		}
+	}
+	p := &BrowserPool{opts: opts, stop: make(chan struct{})}
+	for i := 0; i < opts.Size; i++ {
+		b, err := p.launch()
+		if err != nil {
+			for _, e := range p.entries {
+				e.browser.CloseBrowser()
+			}
+			return nil, err
+		}
+		p.entries = append(p.entries, &poolEntry{browser: b})
+	}
+	go p.healthLoop()
+	return p, nil
+}
+
+/**
+创建并启动一个浏览器
+*/
+func (p *BrowserPool) launch() (*Browser, error) {
+	b := p.opts.New()
+	if err := b.OpenBrowser(); err != nil {
+		return nil, err
+	}
+	if err := b.Connect(); err != nil {
+		b.CloseBrowser()
+		return nil, err
+	}
+	return b, nil
+}
+
+/**
+租用一个标签，从可用的浏览器中选择租出标签最少的一个，全部浏览器都达到MaxTabs时等待其他标签归还
+传参：
+	ctx：上下文，用于控制等待超时和取消
+返回：
+	租用的标签，浏览器池已关闭时error返回ErrPoolClosed，超时或取消时error返回ctx.Err()
+*/
+func (p *BrowserPool) Acquire(ctx context.Context) (*PoolLease, error) {
+	for {
+		p.lock.Lock()
+		if p.closed {
+			p.lock.Unlock()
+			return nil, ErrPoolClosed
+		}
+		var entry *poolEntry
+		for _, e := range p.entries {
+			if e.retiring || e.replacing || (p.opts.MaxTabs > 0 && e.active >= p.opts.MaxTabs) {
+				continue
+			}
+			if entry == nil || e.active < entry.active {
+				entry = e
+			}
+		}
+		if entry != nil {
+			entry.active++
+			entry.uses++
+			if p.opts.MaxUses > 0 && entry.uses >= p.opts.MaxUses {
+				entry.retiring = true
+			}
+			b := entry.browser
+			p.lock.Unlock()
+			lease, err := p.newLease(entry, b)
+			if err != nil {
+				p.finishLease(entry, b)
+				return nil, err
+			}
+			return lease, nil
+		}
+		if p.changed == nil {
+			p.changed = make(chan struct{})
+		}
+		changed := p.changed
+		p.lock.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+/**
+在浏览器中创建标签，开启Incognito时先创建无痕上下文
+*/
+func (p *BrowserPool) newLease(entry *poolEntry, b *Browser) (*PoolLease, error) {
+	lease := &PoolLease{Browser: b, entry: entry}
+	if !p.opts.Incognito {
+		tag, err := b.NewSessionTag("")
+		if err != nil {
+			return nil, err
+		}
+		lease.Tag = tag
+		return lease, nil
+	}
+	ctx, err := b.NewContext(ContextOptions{})
+	if err != nil {
+		return nil, err
+	}
+	tag, err := ctx.NewTag("")
+	if err != nil {
+		ctx.Close()
+		return nil, err
+	}
+	lease.Tag = tag
+	lease.Context = ctx
+	return lease, nil
+}
+
+/**
+归还租用的标签，关闭标签并销毁无痕上下文，浏览器达到MaxUses且全部归还后会被替换，可重复调用
+传参：
+	lease：Acquire返回的租用标签
+返回：
+	成功返回nil，关闭标签失败时返回error错误信息，此时仍视为已归还
+*/
+func (p *BrowserPool) Release(lease *PoolLease) error {
+	p.lock.Lock()
+	if lease.released {
+		p.lock.Unlock()
+		return nil
+	}
+	lease.released = true
+	p.lock.Unlock()
+	targetId := lease.Tag.Id
+	lease.Tag.Close(false)
+	var err error
+	if lease.Context != nil {
+		//关闭上下文时会关闭其中全部标签
+		err = lease.Context.Close()
+	} else {
+		_, err = lease.Browser.Call("Target.closeTarget", map[string]interface{}{"targetId": targetId})
+	}
+	p.finishLease(lease.entry, lease.Browser)
+	return err
+}
+
+/**
+租用结束，减少租出数，待替换的浏览器全部归还后开始替换
+*/
+func (p *BrowserPool) finishLease(entry *poolEntry, b *Browser) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	//浏览器已被替换时，租出数已随替换清零
+	if entry.browser == b {
+		entry.active--
+		p.maybeReplace(entry, false)
+	}
+	p.broadcast()
+}
+
+/**
+替换浏览器，调用前需已加锁
+传参：
+	entry：欲替换的浏览器
+	force：是否立即替换，浏览器已不可用时传true，否则等全部标签归还后才替换
+*/
+func (p *BrowserPool) maybeReplace(entry *poolEntry, force bool) {
+	if p.closed || entry.replacing || !entry.retiring || (entry.active > 0 && !force) {
+		return
+	}
+	entry.replacing = true
+	go p.replace(entry)
+}
+
+/**
+关闭旧浏览器并启动新浏览器，启动失败时等下次健康检查重试
+*/
+func (p *BrowserPool) replace(entry *poolEntry) {
+	entry.browser.CloseBrowser()
+	b, err := p.launch()
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	entry.replacing = false
+	if err == nil {
+		if p.entries == nil {
+			//浏览器池已关闭且不再等待替换完成
+			go b.CloseBrowser()
+			return
+		}
+		entry.browser = b
+		entry.active = 0
+		entry.uses = 0
+		entry.retiring = false
+	}
+	p.broadcast()
+}
+
+/**
+定时检查浏览器是否可用，不可用的浏览器立即替换，其上租出的标签随之失效
+*/
+func (p *BrowserPool) healthLoop() {
+	ticker := time.NewTicker(p.opts.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+		p.lock.Lock()
+		entries := make([]*poolEntry, len(p.entries))
+		copy(entries, p.entries)
+		p.lock.Unlock()
+		for _, e := range entries {
+			p.lock.Lock()
+			b, replacing := e.browser, e.replacing
+			p.lock.Unlock()
+			if replacing {
+				continue
+			}
+			healthy := browserHealthy(b)
+			p.lock.Lock()
+			if e.browser == b && !e.replacing {
+				if !healthy {
+					e.retiring = true
+				}
+				//不可用的立即替换，上次替换失败的也在此重试
+				p.maybeReplace(e, !healthy)
+			}
+			p.lock.Unlock()
+		}
+	}
+}
+
+/**
+浏览器是否可用，进程已退出或/json/version无响应视为不可用
+*/
+func browserHealthy(b *Browser) bool {
+	if b.Err() != nil {
+		return false
+	}
+	res, _, _, err := big.HttpSend(&big.HttpParms{Url: "http://" + b.Ip + ":" + strconv.Itoa(b.Port) + "/json/version"})
+	return err == nil && res != ""
+}
+
+/**
+通知等待者状态已变化，调用前需已加锁
+*/
+func (p *BrowserPool) broadcast() {
+	if p.changed != nil {
+		close(p.changed)
+		p.changed = nil
+	}
+}
+
+/**
+关闭浏览器池，不再租出标签，等待已租出的标签全部归还后关闭全部浏览器
+传参：
+	ctx：上下文，用于控制等待时间，超时或取消时不再等待，直接关闭全部浏览器
+返回：
+	全部归还后关闭返回nil，超时或取消时返回ctx.Err()
+*/
+func (p *BrowserPool) Close(ctx context.Context) error {
+	p.lock.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.stop)
+		//唤醒等待租用的协程
+		p.broadcast()
+	}
+	p.lock.Unlock()
+	err := p.waitIdle(ctx)
+	p.lock.Lock()
+	entries := p.entries
+	p.entries = nil
+	p.lock.Unlock()
+	for _, e := range entries {
+		//替换失败的浏览器已被关闭
+		if e.browser.Err() == nil {
+			e.browser.CloseBrowser()
+		}
+	}
+	return err
+}
+
+/**
+等待已租出的标签全部归还且没有正在替换的浏览器
+*/
+func (p *BrowserPool) waitIdle(ctx context.Context) error {
+	for {
+		p.lock.Lock()
+		busy := false
+		for _, e := range p.entries {
+			if e.active > 0 || e.replacing {
+				busy = true
+			}
+		}
+		if !busy {
+			p.lock.Unlock()
+			return nil
+		}
+		if p.changed == nil {
+			p.changed = make(chan struct{})
+		}
+		changed := p.changed
+		p.lock.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package chrome
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+//会话档案格式版本，格式不兼容地变化时递增，导入时拒绝高于本版本的档案
+const SessionProfileVersion = 1
+
+//在页面中导出当前源的全部IndexedDB数据库，事务在等待后会自动提交，所以每次读取都新开事务
+const idbExportJs = `(async function() {
+	var req = function(r) {
+		return new Promise(function(resolve, reject) {
+			r.onsuccess = function() { resolve(r.result); };
+			r.onerror = function() { reject(r.error); };
+		});
+	};
+	if (!window.indexedDB || !indexedDB.databases) {
+		return [];
+	}
+	var out = [];
+	var infos = await indexedDB.databases();
+	for (var i = 0; i < infos.length; i++) {
+		var db = await req(indexedDB.open(infos[i].name));
+		var item = {name: db.name, version: db.version, stores: []};
+		var names = Array.prototype.slice.call(db.objectStoreNames);
+		for (var j = 0; j < names.length; j++) {
+			var store = db.transaction(names[j], 'readonly').objectStore(names[j]);
+			var indexes = [];
+			for (var k = 0; k < store.indexNames.length; k++) {
+				var idx = store.index(store.indexNames[k]);
+				indexes.push({name: idx.name, keyPath: idx.keyPath, unique: idx.unique, multiEntry: idx.multiEntry});
+			}
+			var keys = await req(store.getAllKeys());
+			var values = await req(db.transaction(names[j], 'readonly').objectStore(names[j]).getAll());
+			var records = [];
+			for (var n = 0; n < keys.length; n++) {
+				records.push({key: keys[n], value: values[n]});
+			}
+			item.stores.push({name: store.name, keyPath: store.keyPath, autoIncrement: store.autoIncrement, indexes: indexes, records: records});
+		}
+		db.close();
+		out.push(item);
+	}
+	return out;
+})()`
+
+//在页面中导入IndexedDB数据库，不存在的库和对象仓库会按导出时的结构创建，记录按主键覆盖，数据库数据作为参数传入
+//库已存在且版本不低于导出时的版本时不会触发onupgradeneeded，此时将版本加1重新打开以创建缺少的对象仓库
+const idbImportJs = `async function(dbs) {
+	var open = function(d, version) {
+		return new Promise(function(resolve, reject) {
+			var r = indexedDB.open(d.name, version);
+			r.onupgradeneeded = function() {
+				var db = r.result;
+				d.stores.forEach(function(s) {
+					if (db.objectStoreNames.contains(s.name)) {
+						return;
+					}
+					var opts = {autoIncrement: s.autoIncrement};
+					if (s.keyPath !== null) {
+						opts.keyPath = s.keyPath;
+					}
+					var store = db.createObjectStore(s.name, opts);
+					(s.indexes || []).forEach(function(idx) {
+						store.createIndex(idx.name, idx.keyPath, {unique: idx.unique, multiEntry: idx.multiEntry});
+					});
+				});
+			};
+			r.onsuccess = function() { resolve(r.result); };
+			r.onerror = function() { reject(r.error); };
+			r.onblocked = function() { reject(new Error('数据库' + d.name + '在其他页面中打开，无法升级版本创建对象仓库')); };
+		});
+	};
+	for (var i = 0; i < dbs.length; i++) {
+		var d = dbs[i];
+		var db = await open(d, d.version);
+		var missing = d.stores.filter(function(s) { return !db.objectStoreNames.contains(s.name); });
+		if (missing.length) {
+			var version = db.version + 1;
+			db.close();
+			db = await open(d, version);
+		}
+		if (!d.stores.length) {
+			db.close();
+			continue;
+		}
+		await new Promise(function(resolve, reject) {
+			var tx = db.transaction(d.stores.map(function(s) { return s.name; }), 'readwrite');
+			d.stores.forEach(function(s) {
+				var store = tx.objectStore(s.name);
+				(s.records || []).forEach(function(rec) {
+					if (store.keyPath !== null) {
+						store.put(rec.value);
+					} else {
+						store.put(rec.value, rec.key);
+					}
+				});
+			});
+			tx.oncomplete = function() { db.close(); resolve(); };
+			tx.onerror = function() { db.close(); reject(tx.error); };
+			tx.onabort = function() { db.close(); reject(tx.error); };
+		});
+	}
+	return true;
+}`
+
+//在新文档的脚本执行前写入源的localStorage和sessionStorage，只写入不存在的键，不覆盖页面已有的数据
+const storageRestoreJs = `(function(s) {
+	if (location.origin !== s.origin) {
+		return;
+	}
+	var put = function(name, items) {
+		try {
+			var storage = window[name];
+			Object.keys(items || {}).forEach(function(k) {
+				if (storage.getItem(k) === null) {
+					storage.setItem(k, items[k]);
+				}
+			});
+		} catch (e) {}
+	};
+	put('localStorage', s.localStorage);
+	put('sessionStorage', s.sessionStorage);
+})(%s)`
+
+/**
+导出会话档案，包括浏览器的全部Cookie、各源的localStorage和sessionStorage，可选导出IndexedDB
+只有在本标签某个框架中打开的源才能读取存储，Origins中未打开的源会被跳过
+传参：
+	opts：导出选项，传SessionOptions{}表示只导出Cookie和本标签各框架所在源的存储数据
+返回：
+	会话档案，失败返回error错误信息
+*/
+func (p *Tag) ExportSession(opts SessionOptions) (*SessionProfile, error) {
+	profile := &SessionProfile{Version: SessionProfileVersion, Created: time.Now()}
+	var err error
+	if profile.Cookies, _, err = p.CookiesGet(""); err != nil {
+		return nil, err
+	}
+	if profile.UserAgent, err = p.userAgent(); err != nil {
+		return nil, err
+	}
+	origins, err := p.frameOrigins()
+	if err != nil {
+		return nil, err
+	}
+	opened := len(origins)
+	for _, v := range opts.Origins {
+		origins = appendOrigin(origins, strings.TrimSuffix(v, "/"))
+	}
+	if _, err = p.Call("DOMStorage.enable", nil); err != nil {
+		return nil, err
+	}
+	current := ""
+	if opened > 0 {
+		current = origins[0]
+	}
+	for i, origin := range origins {
+		s := OriginStorage{Origin: origin}
+		if s.LocalStorage, err = p.storageItems(origin, true); err == nil {
+			s.SessionStorage, err = p.storageItems(origin, false)
+		}
+		if err != nil {
+			//额外指定的源没有打开时浏览器找不到对应框架，跳过
+			if i >= opened {
+				continue
+			}
+			return nil, err
+		}
+		if opts.IndexedDB && origin == current {
+			if s.IndexedDB, err = p.evalValue(idbExportJs); err != nil {
+				return nil, err
+			}
+		}
+		profile.Origins = append(profile.Origins, s)
+	}
+	return profile, nil
+}
+
+/**
+导出会话档案并保存为JSON文件
+传参：
+	path：文件路径
+	opts：导出选项
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *Tag) ExportSessionToFile(path string, opts SessionOptions) error {
+	profile, err := p.ExportSession(opts)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(profile, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+/**
+导入会话档案，依次写入Cookie、各源的localStorage和sessionStorage，IndexedDB只导入页面当前所在源的数据，
+导入后打开或刷新对应网址即可恢复登录状态
+本标签已打开的源直接写入存储；未打开的源在本标签之后打开该源时，于页面脚本执行前写入，已存在的键不覆盖
+某个源导入失败时继续导入其余的源，最后返回*SessionImportError列出失败的源
+传参：
+	profile：ExportSession导出的会话档案
+返回：
+	成功返回nil，档案版本高于本库支持的版本时返回ErrInvalidParam，部分源失败返回*SessionImportError，其他失败返回error错误信息
+*/
+func (p *Tag) ImportSession(profile *SessionProfile) error {
+	if profile == nil || profile.Version > SessionProfileVersion {
+		return ErrInvalidParam
+	}
+	if err := p.CookiesSetStu(profile.Cookies); err != nil {
+		return err
+	}
+	opened, _ := p.frameOrigins()
+	current := ""
+	if len(opened) > 0 {
+		current = opened[0]
+	}
+	enabled := false
+	failed := make(map[string]error)
+	for _, s := range profile.Origins {
+		var err error
+		if hasOrigin(opened, s.Origin) {
+			if !enabled {
+				_, err = p.Call("DOMStorage.enable", nil)
+				enabled = err == nil
+			}
+			if err == nil {
+				err = p.setStorageItems(s.Origin, true, s.LocalStorage)
+			}
+			if err == nil {
+				err = p.setStorageItems(s.Origin, false, s.SessionStorage)
+			}
+		} else {
+			err = p.restoreStorageOnLoad(s)
+		}
+		if err == nil && len(s.IndexedDB) > 0 && s.Origin == current {
+			err = p.importIndexedDB(s.IndexedDB)
+		}
+		if err != nil {
+			failed[s.Origin] = err
+		}
+	}
+	if len(failed) > 0 {
+		return &SessionImportError{Origins: failed}
+	}
+	return nil
+}
+
+/**
+从JSON文件导入会话档案
+传参：
+	path：ExportSessionToFile保存的文件路径
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *Tag) ImportSessionFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var profile SessionProfile
+	if err = json.Unmarshal(data, &profile); err != nil {
+		return err
+	}
+	return p.ImportSession(&profile)
+}
+
+/**
+取本标签各框架当前所在的源，首个为主框架的源，忽略about:blank等没有源的框架
+*/
+func (p *Tag) frameOrigins() ([]string, error) {
+	res, err := p.Call("Page.getFrameTree", nil)
+	if err != nil {
+		return nil, err
+	}
+	type frameTree struct {
+		Frame struct {
+			SecurityOrigin string `json:"securityOrigin"`
+		} `json:"frame"`
+		ChildFrames []json.RawMessage `json:"childFrames"`
+	}
+	var rsp struct {
+		Result struct {
+			FrameTree json.RawMessage `json:"frameTree"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return nil, err
+	}
+	origins := make([]string, 0)
+	queue := []json.RawMessage{rsp.Result.FrameTree}
+	for len(queue) > 0 {
+		var tree frameTree
+		if err = json.Unmarshal(queue[0], &tree); err != nil {
+			return nil, err
+		}
+		queue = append(queue[1:], tree.ChildFrames...)
+		origins = appendOrigin(origins, tree.Frame.SecurityOrigin)
+	}
+	return origins, nil
+}
+
+/**
+追加源并去重，只保留http和https的源
+*/
+func appendOrigin(origins []string, origin string) []string {
+	if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+		return origins
+	}
+	if hasOrigin(origins, origin) {
+		return origins
+	}
+	return append(origins, origin)
+}
+
+/**
+判断源是否在列表中
+*/
+func hasOrigin(origins []string, origin string) bool {
+	for _, v := range origins {
+		if v == origin {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+取源的localStorage或sessionStorage全部数据
+*/
+func (p *Tag) storageItems(origin string, local bool) (map[string]string, error) {
+	parm := make(map[string]interface{})
+	parm["storageId"] = map[string]interface{}{"securityOrigin": origin, "isLocalStorage": local}
+	res, err := p.Call("DOMStorage.getDOMStorageItems", parm)
+	if err != nil {
+		return nil, err
+	}
+	var rsp struct {
+		Result struct {
+			Entries [][]string `json:"entries"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.Result.Entries) == 0 {
+		return nil, nil
+	}
+	items := make(map[string]string)
+	for _, v := range rsp.Result.Entries {
+		if len(v) == 2 {
+			items[v[0]] = v[1]
+		}
+	}
+	return items, nil
+}
+
+/**
+写入源的localStorage或sessionStorage数据
+*/
+func (p *Tag) setStorageItems(origin string, local bool, items map[string]string) error {
+	for k, v := range items {
+		parm := make(map[string]interface{})
+		parm["storageId"] = map[string]interface{}{"securityOrigin": origin, "isLocalStorage": local}
+		parm["key"] = k
+		parm["value"] = v
+		if _, err := p.Call("DOMStorage.setDOMStorageItem", parm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/**
+注册在新文档加载时恢复源存储的脚本，用于本标签尚未打开的源，数据重新序列化为JSON后嵌入脚本
+*/
+func (p *Tag) restoreStorageOnLoad(s OriginStorage) error {
+	if len(s.LocalStorage) == 0 && len(s.SessionStorage) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(OriginStorage{Origin: s.Origin, LocalStorage: s.LocalStorage, SessionStorage: s.SessionStorage})
+	if err != nil {
+		return err
+	}
+	parm := make(map[string]interface{})
+	parm["source"] = fmt.Sprintf(storageRestoreJs, data)
+	_, err = p.Call("Page.addScriptToEvaluateOnNewDocument", parm)
+	return err
+}
+
+/**
+在主框架中导入IndexedDB数据，数据作为调用参数传入，不拼接到脚本中
+*/
+func (p *Tag) importIndexedDB(data json.RawMessage) error {
+	if !json.Valid(data) {
+		return ErrInvalidParam
+	}
+	win, err := p.evalObject("window", 0)
+	if err != nil {
+		return err
+	}
+	defer p.releaseObject(win.ObjectId)
+	_, err = p.callFunctionOn(win.ObjectId, idbImportJs, true, data)
+	return err
+}
+
+/**
+取浏览器的UserAgent
+*/
+func (p *Tag) userAgent() (string, error) {
+	res, err := p.Call("Browser.getVersion", nil)
+	if err != nil {
+		return "", err
+	}
+	var rsp struct {
+		Result struct {
+			UserAgent string `json:"userAgent"`
+		} `json:"result"`
+	}
+	err = json.Unmarshal([]byte(res), &rsp)
+	return rsp.Result.UserAgent, err
+}
+
+/**
+在主框架中执行表达式并等待Promise完成，返回结果的JSON
+*/
+func (p *Tag) evalValue(expression string) (json.RawMessage, error) {
+	parm := make(map[string]interface{})
+	parm["expression"] = expression
+	parm["returnByValue"] = true
+	parm["awaitPromise"] = true
+	res, err := p.Call("Runtime.evaluate", parm)
+	if err != nil {
+		return nil, err
+	}
+	var rsp struct {
+		Result struct {
+			Result struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"result"`
+			ExceptionDetails json.RawMessage `json:"exceptionDetails"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return nil, err
+	}
+	if len(rsp.Result.ExceptionDetails) > 0 {
+		return nil, newJsError(string(rsp.Result.ExceptionDetails))
+	}
+	if len(rsp.Result.Result.Value) == 0 {
+		return nil, errors.New("表达式没有返回值")
+	}
+	return rsp.Result.Result.Value, nil
+}
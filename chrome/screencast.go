@@ -0,0 +1,280 @@
+package chrome
+
+import (
+	"b/big"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//录屏帧队列长度，写入跟不上时丢弃多出的帧
+const screencastQueue = 64
+
+//MJPEG各帧之间的分隔符
+const mjpegBoundary = "planbframe"
+
+//正在进行的录屏
+type screencast struct {
+	frames      chan screencastFrame //待写入的帧，按收到的顺序写入
+	writer      frameWriter          //帧写入器
+	unsubscribe func()               //取消订阅帧事件
+	stopped     chan struct{}        //结束录屏通知，关闭后帧回调不再入队，写入协程写完队列中剩余的帧后退出
+	done        chan struct{}        //写入协程退出通知
+	err         error                //写入过程中的首个错误
+}
+
+//录屏帧
+type screencastFrame struct {
+	data      []byte  //图片内容
+	timestamp float64 //截取时间，单位秒
+	sessionId int     //用于确认收到该帧
+}
+
+//帧写入器
+type frameWriter interface {
+	write(data []byte, timestamp float64) error
+	close() error
+}
+
+/**
+开始录屏，浏览器推送的每一帧都会按顺序写入到目录或文件，可用于记录自动化运行失败的过程，
+需调用StopScreencast结束录屏，页面没有变化时浏览器不会推送新帧
+传参：
+	opts：录屏选项，Dir和File必须且只能设置一个
+返回：
+	成功返回nil，参数错误或已在录屏时返回ErrInvalidParam，失败时error返回具体信息
+例：
+	tag.StartScreencast(chrome.ScreencastOptions{File: "run.mjpeg", Quality: 60})
+	defer tag.StopScreencast()
+*/
+func (p *Tag) StartScreencast(opts ScreencastOptions) error {
+	if (opts.Dir == "") == (opts.File == "") {
+		return ErrInvalidParam
+	}
+	//开始和结束录屏互斥，防止并发开始时都通过检查；期间需调用Call，不能持有taskLock
+	p.screencastLock.Lock()
+	defer p.screencastLock.Unlock()
+	p.taskLock.Lock()
+	running := p.screencast != nil
+	p.taskLock.Unlock()
+	if running {
+		return ErrInvalidParam
+	}
+	var writer frameWriter
+	var err error
+	if opts.Dir != "" {
+		if opts.Format == "" {
+			opts.Format = "jpeg"
+		}
+		writer, err = newDirWriter(opts.Dir, opts.Format)
+	} else {
+		switch strings.ToLower(filepath.Ext(opts.File)) {
+		case ".mjpeg", ".mjpg":
+			opts.Format = "jpeg"
+			writer, err = newMjpegWriter(opts.File)
+		case ".png", ".apng":
+			opts.Format = "png"
+			writer, err = newApngWriter(opts.File)
+		default:
+			return ErrInvalidParam
+		}
+	}
+	if err != nil {
+		return err
+	}
+	sc := &screencast{
+		frames:  make(chan screencastFrame, screencastQueue),
+		writer:  writer,
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	//回调在消息监听协程中执行，不能在回调里确认帧，交给写入协程处理
+	sc.unsubscribe = p.On("Page.screencastFrame", func(params json.RawMessage) {
+		var ev struct {
+			Data     string `json:"data"`
+			Metadata struct {
+				Timestamp float64 `json:"timestamp"`
+			} `json:"metadata"`
+			SessionId int `json:"sessionId"`
+		}
+		if json.Unmarshal(params, &ev) != nil {
+			return
+		}
+		data, err := big.EnCodeBase64Un([]byte(ev.Data))
+		if err != nil {
+			return
+		}
+		if ev.Metadata.Timestamp == 0 {
+			ev.Metadata.Timestamp = float64(time.Now().UnixNano()) / 1e9
+		}
+		//取消订阅前已开始执行的回调可能在结束录屏后才执行到这里
+		select {
+		case <-sc.stopped:
+			return
+		default:
+		}
+		select {
+		case <-sc.stopped:
+		case sc.frames <- screencastFrame{data: data, timestamp: ev.Metadata.Timestamp, sessionId: ev.SessionId}:
+		default:
+			//队列已满，丢弃该帧，但仍需确认，否则浏览器不再推送
+			go p.screencastAck(ev.SessionId)
+		}
+	})
+	go p.screencastWorker(sc)
+	parm := make(map[string]interface{})
+	parm["format"] = opts.Format
+	if opts.Quality > 0 {
+		parm["quality"] = opts.Quality
+	}
+	if opts.MaxWidth > 0 {
+		parm["maxWidth"] = opts.MaxWidth
+	}
+	if opts.MaxHeight > 0 {
+		parm["maxHeight"] = opts.MaxHeight
+	}
+	if opts.EveryNthFrame > 0 {
+		parm["everyNthFrame"] = opts.EveryNthFrame
+	}
+	if _, err = p.Call("Page.startScreencast", parm); err != nil {
+		sc.stop()
+		return err
+	}
+	p.taskLock.Lock()
+	p.screencast = sc
+	p.taskLock.Unlock()
+	return nil
+}
+
+/**
+结束录屏，等待已收到的帧全部写入后关闭文件
+返回：
+	成功返回nil，未在录屏时返回ErrInvalidParam，写入过程中出错时返回首个错误
+*/
+func (p *Tag) StopScreencast() error {
+	p.screencastLock.Lock()
+	defer p.screencastLock.Unlock()
+	p.taskLock.Lock()
+	sc := p.screencast
+	p.screencast = nil
+	p.taskLock.Unlock()
+	if sc == nil {
+		return ErrInvalidParam
+	}
+	_, err := p.Call("Page.stopScreencast", nil)
+	if serr := sc.stop(); serr != nil {
+		err = serr
+	}
+	return err
+}
+
+/**
+取消订阅帧事件，等待写入协程写完剩余的帧后关闭写入器
+帧队列不关闭，仍在执行的帧回调向其发送也不会出错
+*/
+func (sc *screencast) stop() error {
+	sc.unsubscribe()
+	close(sc.stopped)
+	<-sc.done
+	if err := sc.writer.close(); sc.err == nil {
+		sc.err = err
+	}
+	return sc.err
+}
+
+/**
+按顺序确认并写入帧，结束录屏后写完队列中剩余的帧再退出
+*/
+func (p *Tag) screencastWorker(sc *screencast) {
+	defer close(sc.done)
+	for {
+		select {
+		case frame := <-sc.frames:
+			p.screencastWrite(sc, frame)
+		case <-sc.stopped:
+			for {
+				select {
+				case frame := <-sc.frames:
+					p.screencastWrite(sc, frame)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+/**
+确认并写入一帧，出错后不再写入
+*/
+func (p *Tag) screencastWrite(sc *screencast, frame screencastFrame) {
+	p.screencastAck(frame.sessionId)
+	if sc.err == nil {
+		sc.err = sc.writer.write(frame.data, frame.timestamp)
+	}
+}
+
+/**
+确认收到帧，浏览器收到确认后才会推送下一帧
+*/
+func (p *Tag) screencastAck(sessionId int) {
+	p.Call("Page.screencastFrameAck", map[string]interface{}{"sessionId": sessionId})
+}
+
+//将每帧保存为单独的图片文件
+type dirWriter struct {
+	dir   string //保存目录
+	ext   string //文件扩展名
+	index int    //帧序号
+}
+
+func newDirWriter(dir string, format string) (*dirWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &dirWriter{dir: dir, ext: format}, nil
+}
+
+func (w *dirWriter) write(data []byte, timestamp float64) error {
+	w.index++
+	name := fmt.Sprintf("%06d_%d.%s", w.index, int64(timestamp*1000), w.ext)
+	return os.WriteFile(filepath.Join(w.dir, name), data, 0644)
+}
+
+func (w *dirWriter) close() error {
+	return nil
+}
+
+//将帧保存为MJPEG（multipart/x-mixed-replace）文件，每帧带有X-Timestamp头记录截取时间
+type mjpegWriter struct {
+	f   *os.File
+	buf *bufio.Writer
+}
+
+func newMjpegWriter(path string) (*mjpegWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &mjpegWriter{f: f, buf: bufio.NewWriter(f)}, nil
+}
+
+func (w *mjpegWriter) write(data []byte, timestamp float64) error {
+	fmt.Fprintf(w.buf, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\nX-Timestamp: %.3f\r\n\r\n", mjpegBoundary, len(data), timestamp)
+	w.buf.Write(data)
+	_, err := w.buf.WriteString("\r\n")
+	return err
+}
+
+func (w *mjpegWriter) close() error {
+	w.buf.WriteString("--" + mjpegBoundary + "--\r\n")
+	err := w.buf.Flush()
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
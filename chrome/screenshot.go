@@ -0,0 +1,178 @@
+package chrome
+
+import (
+	"b/big"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/**
+网页截图，与TagCaptureScreenshot不同的是本方法直接返回图片内容，并可截取整个页面或指定元素
+传参：
+	opts：截图选项，传ScreenshotOptions{}表示以png格式截取当前可见区域
+返回：
+	图片内容，未找到Selector对应的元素时返回ErrNotFound，失败时error返回具体信息
+例：
+	img, err := tag.Screenshot(chrome.ScreenshotOptions{FullPage: true, Format: "jpeg", Quality: 80})
+*/
+func (p *Tag) Screenshot(opts ScreenshotOptions) ([]byte, error) {
+	if opts.Format == "" {
+		opts.Format = "png"
+	}
+	parm := make(map[string]interface{})
+	parm["format"] = opts.Format
+	if opts.Format != "png" && opts.Quality > 0 {
+		parm["quality"] = opts.Quality
+	}
+	clip := opts.Clip
+	if opts.Selector != "" {
+		box, err := p.elementPageBox(opts.Frame, opts.Selector)
+		if err != nil {
+			return nil, err
+		}
+		clip = &box
+	} else if clip == nil && opts.FullPage {
+		width, height, err := p.contentSize()
+		if err != nil {
+			return nil, err
+		}
+		clip = &Rect{Width: width, Height: height}
+	}
+	if clip != nil {
+		parm["clip"] = map[string]interface{}{
+			"x":      clip.X,
+			"y":      clip.Y,
+			"width":  clip.Width,
+			"height": clip.Height,
+			"scale":  1,
+		}
+		//截取可见区域以外的部分
+		parm["captureBeyondViewport"] = true
+	}
+	if opts.OmitBackground {
+		color := map[string]interface{}{"r": 0, "g": 0, "b": 0, "a": 0}
+		if _, err := p.Call("Emulation.setDefaultBackgroundColorOverride", map[string]interface{}{"color": color}); err != nil {
+			return nil, err
+		}
+		//截图后恢复默认背景色
+		defer p.Call("Emulation.setDefaultBackgroundColorOverride", nil)
+	}
+	res, err := p.Call("Page.captureScreenshot", parm)
+	if err != nil {
+		return nil, err
+	}
+	var rsp struct {
+		Result struct {
+			Data string `json:"data"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return nil, err
+	}
+	return big.EnCodeBase64Un([]byte(rsp.Result.Data))
+}
+
+/**
+网页截图并保存到文件，未指定opts.Format时按文件扩展名决定格式（.jpg、.jpeg、.webp，其他为png）
+传参：
+	path：保存的文件路径，文件已存在时覆盖
+	opts：截图选项，同Screenshot
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *Tag) ScreenshotToFile(path string, opts ScreenshotOptions) error {
+	if opts.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jpg", ".jpeg":
+			opts.Format = "jpeg"
+		case ".webp":
+			opts.Format = "webp"
+		}
+	}
+	data, err := p.Screenshot(opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+/**
+取页面内容的完整尺寸，单位CSS像素
+*/
+func (p *Tag) contentSize() (width float64, height float64, err error) {
+	metrics, err := p.layoutMetrics()
+	if err != nil {
+		return 0, 0, err
+	}
+	return metrics.ContentSize.Width, metrics.ContentSize.Height, nil
+}
+
+//页面布局尺寸，仅取用到的字段
+type layoutMetrics struct {
+	ContentSize    Rect `json:"contentSize"` //页面内容尺寸
+	VisualViewport struct {
+		PageX float64 `json:"pageX"` //可见区域相对于页面左上角的X坐标，即横向滚动距离
+		PageY float64 `json:"pageY"` //可见区域相对于页面左上角的Y坐标，即纵向滚动距离
+	} `json:"visualViewport"`
+}
+
+/**
+取页面布局尺寸，新版浏览器的css前缀字段单位为CSS像素，优先使用
+*/
+func (p *Tag) layoutMetrics() (layoutMetrics, error) {
+	var metrics layoutMetrics
+	res, err := p.Call("Page.getLayoutMetrics", nil)
+	if err != nil {
+		return metrics, err
+	}
+	var rsp struct {
+		Result struct {
+			layoutMetrics
+			CssContentSize    *Rect `json:"cssContentSize"`
+			CssVisualViewport *struct {
+				PageX float64 `json:"pageX"`
+				PageY float64 `json:"pageY"`
+			} `json:"cssVisualViewport"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+		return metrics, err
+	}
+	metrics = rsp.Result.layoutMetrics
+	if rsp.Result.CssContentSize != nil {
+		metrics.ContentSize = *rsp.Result.CssContentSize
+	}
+	if rsp.Result.CssVisualViewport != nil {
+		metrics.VisualViewport.PageX = rsp.Result.CssVisualViewport.PageX
+		metrics.VisualViewport.PageY = rsp.Result.CssVisualViewport.PageY
+	}
+	return metrics, nil
+}
+
+/**
+取元素相对于页面左上角的区域，会先滚动页面使元素可见
+*/
+func (p *Tag) elementPageBox(contextId int, selector string) (Rect, error) {
+	el, err := p.QueryFrame(contextId, selector)
+	if err != nil {
+		return Rect{}, err
+	}
+	defer el.Release()
+	if err = el.ScrollIntoView(); err != nil {
+		return Rect{}, err
+	}
+	box, err := el.BoundingBox()
+	if err != nil {
+		return Rect{}, err
+	}
+	metrics, err := p.layoutMetrics()
+	if err != nil {
+		return Rect{}, err
+	}
+	//BoundingBox相对于可见区域，加上滚动距离转为相对于页面
+	box.X += metrics.VisualViewport.PageX
+	box.Y += metrics.VisualViewport.PageY
+	return box, nil
+}
@@ -0,0 +1,236 @@
+package chrome
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+//自动重启后标签无法恢复的事件名，由OnTagLost订阅
+const tagLostEvent = "planb.tagLost"
+
+/**
+浏览器退出通知，OpenBrowser启动的浏览器进程退出（包括崩溃和调用CloseBrowser）后通道关闭，
+开启AutoRelaunch时只有重启失败或调用CloseBrowser才会关闭；连接已运行的浏览器时通道不会关闭
+返回：
+	退出通知通道，关闭后可通过Err取退出原因
+例：
+	select {
+	case <-browser.Done():
+		fmt.Println("浏览器已退出：", browser.Err())
+	case <-ctx.Done():
+	}
+*/
+func (p *Browser) Done() <-chan struct{} {
+	p.connLock.Lock()
+	defer p.connLock.Unlock()
+	if p.done == nil {
+		p.done = make(chan struct{})
+	}
+	return p.done
+}
+
+/**
+浏览器退出原因
+返回：
+	运行中返回nil，调用CloseBrowser关闭时返回ErrBrowserClosed，进程异常退出时返回包装了ErrBrowserExited的错误，可用errors.Is判断
+*/
+func (p *Browser) Err() error {
+	p.connLock.Lock()
+	defer p.connLock.Unlock()
+	return p.exitErr
+}
+
+/**
+记录退出原因并关闭退出通知通道，只有首次调用生效
+*/
+func (p *Browser) finish(err error) {
+	p.connLock.Lock()
+	defer p.connLock.Unlock()
+	if p.exitErr != nil {
+		return
+	}
+	p.exitErr = err
+	if p.done == nil {
+		p.done = make(chan struct{})
+	}
+	close(p.done)
+}
+
+/**
+监控浏览器进程，进程不是由CloseBrowser结束时视为异常退出，按AutoRelaunch决定是否重启
+*/
+func (p *Browser) supervise(proc *browserProcess) {
+	<-proc.exited
+	p.connLock.Lock()
+	if p.process != proc {
+		//CloseBrowser主动关闭
+		p.connLock.Unlock()
+		return
+	}
+	p.process = nil
+	relaunch := p.AutoRelaunch
+	p.connLock.Unlock()
+	p.Disconnect()
+	err := fmt.Errorf("%w：%v", ErrBrowserExited, proc.err)
+	if relaunch {
+		rerr := p.relaunch()
+		if rerr == nil {
+			return
+		}
+		err = fmt.Errorf("%w，自动重启失败：%v", err, rerr)
+	}
+	p.finish(err)
+}
+
+/**
+使用相同的端口和DataDir重启浏览器，按原选项重建浏览器上下文、恢复下载设置、重新打开通过浏览器附加的标签并恢复自动附加
+上下文重建失败时，其中的标签不会在默认上下文中打开，与打开失败的标签一样丢弃并通过OnTagLost通知
+*/
+func (p *Browser) relaunch() error {
+	if err := p.launch(); err != nil {
+		return err
+	}
+	if err := p.Connect(); err != nil {
+		return err
+	}
+	p.connLock.Lock()
+	dir := p.downloadDir
+	handler := p.attachEvent
+	p.connLock.Unlock()
+	//先重建上下文，上下文中的标签需在新上下文中打开
+	lost := make(map[*BrowserContext]error)
+	p.contexts.Range(func(key, value interface{}) bool {
+		ctx := key.(*BrowserContext)
+		id, err := p.createContext(ctx.opts)
+		if err != nil {
+			lost[ctx] = fmt.Errorf("重建浏览器上下文失败：%w", err)
+			p.contexts.Delete(ctx)
+			return true
+		}
+		ctx.Id = id
+		return true
+	})
+	//上下文重建后再恢复下载设置，重建的上下文一并生效
+	if dir != "" {
+		p.SetDownloadBehavior(dir)
+	}
+	p.tags.Range(func(key, value interface{}) bool {
+		tag := key.(*Tag)
+		err, ok := lost[tag.browserContext]
+		if !ok {
+			err = p.reattach(tag)
+		}
+		if err != nil {
+			p.tags.Delete(tag)
+			p.tagLost(tag, err)
+		}
+		return true
+	})
+	if handler != nil {
+		return p.AutoAttach(handler)
+	}
+	return nil
+}
+
+/**
+在重启后的浏览器中重新打开标签原来的网址，并将标签对象绑定到新目标，绑定后原标签对象可继续使用，
+标签的Id会变为新目标ID，HookFetchEn等拦截需重新开启
+*/
+func (p *Browser) reattach(tag *Tag) error {
+	contextId := ""
+	if tag.browserContext != nil {
+		contextId = tag.browserContext.Id
+	}
+	targetId, err := p.createTarget(tag.Url, contextId)
+	if err != nil {
+		return err
+	}
+	done := p.markAttaching(targetId)
+	defer done()
+	sessionId, err := p.attachTarget(targetId)
+	if err != nil {
+		return err
+	}
+	p.connLock.Lock()
+	c := p.session.conn
+	p.connLock.Unlock()
+	s, err := c.newSession(sessionId, tag.onEventMsg)
+	if err != nil {
+		return err
+	}
+	tag.taskLock.Lock()
+	tag.Id = targetId
+	tag.WebSocketDebuggerUrl = "ws://" + p.Ip + ":" + strconv.Itoa(p.Port) + "/devtools/page/" + targetId
+	tag.session = s
+	tag.taskLock.Unlock()
+	tag.lifecycle.reset()
+	tag.contextIds.Range(func(key, value interface{}) bool {
+		tag.contextIds.Delete(key)
+		return true
+	})
+	return tag.enable()
+}
+
+/**
+订阅自动重启后无法恢复的标签，所属上下文重建失败或重新打开失败的标签会被丢弃，不再随浏览器重启恢复
+回调同On，不可在回调内直接调用Call
+传参：
+	handler：回调，targetId为丢弃前的标签ID，err为失败原因
+返回：
+	取消订阅的函数
+例：
+	browser.OnTagLost(func(targetId string, err error) {
+		fmt.Println("标签已丢失：", targetId, err)
+	})
+*/
+func (p *Browser) OnTagLost(handler func(targetId string, err error)) (unsubscribe func()) {
+	return p.events.on(tagLostEvent, func(params json.RawMessage) {
+		var ev struct {
+			TargetId string `json:"targetId"`
+			Error    string `json:"error"`
+		}
+		if json.Unmarshal(params, &ev) == nil {
+			handler(ev.TargetId, errors.New(ev.Error))
+		}
+	})
+}
+
+/**
+通知订阅者标签已丢失
+*/
+func (p *Browser) tagLost(tag *Tag, err error) {
+	if !p.events.has(tagLostEvent) {
+		return
+	}
+	jbyte, _ := json.Marshal(map[string]interface{}{"targetId": tag.Id, "error": err.Error()})
+	p.events.emit(tagLostEvent, jbyte)
+}
+
+/**
+取附加会话的会话ID，自行连接或未连接时返回空字符串
+*/
+func (p *Tag) sessionId() string {
+	p.taskLock.Lock()
+	defer p.taskLock.Unlock()
+	if p.session == nil {
+		return ""
+	}
+	return p.session.id
+}
+
+/**
+将标签的崩溃、分离事件转发给浏览器级订阅者，事件的params中增加targetId字段标明是哪个标签
+*/
+func (p *Tag) forwardInspector(method string, params json.RawMessage) {
+	b := p.browser
+	if b == nil || !b.events.has(method) {
+		return
+	}
+	ev := make(map[string]interface{})
+	json.Unmarshal(params, &ev)
+	ev["targetId"] = p.Id
+	jbyte, _ := json.Marshal(ev)
+	b.events.emit(method, jbyte)
+}
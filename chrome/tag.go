@@ -1,14 +1,13 @@
-//本库仅支持Windows平台的所有使用Chrome内核的浏览器,线程安全的
+//本库支持Windows、Linux、macOS平台的所有使用Chrome内核的浏览器,线程安全的
 //Tag结构体用于操作浏览器页面标签
 package chrome
 
 import (
 	"b/big"
+	"context"
 	"encoding/json"
 	"fmt"
 	simplejson "github.com/bitly/go-simplejson"
-	"github.com/gorilla/websocket"
-	"math"
 	"net/url"
 	"strconv"
 	"strings"
@@ -26,23 +25,29 @@ type Tag struct {
 	Typ                  string                                    `json:"type"`                 //标签页类型：page（页面）、background_page（插件）、iframe（内嵌页）
 	Url                  string                                    `json:"url"`                  //标签页链接
 	WebSocketDebuggerUrl string                                    `json:"webSocketDebuggerUrl"` //WebSocket链接
+	CallTimeout          time.Duration                             `json:"-"`                    //Call方法等待响应的超时时间，可空，默认为DefaultCallTimeout
 	dialogOpenEvent      func(d DialogOpen)                        //对话框打开监听事件，当网页弹出对话框(Alert,Confirm,Prompt,Beforeunload)时自动触发
 	dialogCloseEvent     func(d DialogClose)                       //对话框关闭监听事件，当网页关闭对话框时自动触发
 	Frames               []frame                                   //框架集合，首个成员为主框架信息，其他均为子框架，本对象内有框架信息及网页音视频图资源信息
-	connect              bool                                      //连接成功为true，断开或失败为false
-	ws                   *websocket.Conn                           //ws连接对象
+	session              *session                                  //连接会话，自行连接时独占一个ws连接，通过Browser附加时共用浏览器的ws连接
+	browser              *Browser                                  //所属的浏览器，通过Browser获取或附加的标签才有，手动构造的标签为nil
+	browserContext       *BrowserContext                           //所属的浏览器上下文，在NewContext创建的上下文中打开的标签才有，默认上下文为nil
 	taskLock             sync.Mutex                                //互斥锁
-	taskId               int                                       //任务ID，自增
-	taskRsp              sync.Map                                  //任务响应结果，key是任务id
 	contextIds           sync.Map                                  //标签上下文ID集合，key是frameId，value是contextId
-	readyState           frameLoadStep                             //标签页是否加载完成，结构体中全部字段为true，且frameStoppedLoading字段等于2才算加载完成
+	lifecycle            lifecycle                                 //页面生命周期跟踪，用于判断页面是否加载完成
 	logs                 []ConsoleLog                              //控制台输出日志集合
 	logsLock             sync.Mutex                                //控制台日志操作互斥锁
 	haveDialog           bool                                      //是否存在对话框
 	px                   int                                       //鼠标在浏览器的x坐标
 	py                   int                                       //鼠标在浏览器的y坐标
+	modifiers            int                                       //当前通过KeyDown按住的功能键，Alt = 1，Ctrl = 2，Meta/Command = 4，Shift = 8
+	screencast           *screencast                               //正在进行的录屏，未录屏时为nil
+	screencastLock       sync.Mutex                                //开始、结束录屏互斥锁
 	hookReqEvent         func(tag *Tag, request HookHttpRequest)   //拦截请求的回调方法
 	hookRespEvent        func(tag *Tag, response HookHttpResponse) //拦截响应的回调方法
+	fetchEvent           func(tag *Tag, req *FetchRequest)         //拦截并修改请求的回调方法
+	fileChooserEvent     func(tag *Tag, chooser *FileChooser)      //拦截文件选择对话框的回调方法
+	events               eventBus                                  //事件订阅总线，通过On方法订阅
 }
 
 /**
@@ -54,30 +59,60 @@ type Tag struct {
 func (p *Tag) Connect() (bool, error) {
 	p.taskLock.Lock()
 	//判断标签是否连接
-	if p.connect {
+	if p.session != nil && !p.session.isClosed() {
 		//已经连接过了
 		p.taskLock.Unlock()
 		return true, nil
 	}
+	if p.session != nil && p.session.id != "" {
+		//通过Browser附加的会话已结束，需重新调用Browser.AttachTag附加
+		p.taskLock.Unlock()
+		return false, ErrDisconnected
+	}
 	u, err := url.Parse(p.WebSocketDebuggerUrl)
 	if err != nil {
 		p.taskLock.Unlock()
 		return false, err
 	}
-	p.ws, _, err = websocket.DefaultDialer.Dial(u.String(), nil)
+	c, err := dialConn(u.String())
 	if err != nil {
 		p.taskLock.Unlock()
 		return false, err
 	}
-	p.connect = true
-	go p.onListenerMsg()
+	p.session, err = c.newSession("", p.onEventMsg)
 	p.taskLock.Unlock()
+	p.lifecycle.reset()
+	if err != nil {
+		return false, err
+	}
 	//开启各项事件
-	p.Call("Page.enable", nil)
-	p.Call("Runtime.enable", nil)
+	if err = p.enable(); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
+/**
+开启标签的各项事件
+*/
+func (p *Tag) enable() error {
+	if p.Typ == "" || p.Typ == "page" || p.Typ == "iframe" {
+		if _, err := p.Call("Page.enable", nil); err != nil {
+			return err
+		}
+		//开启生命周期事件，开启时浏览器会推送当前已触发的事件
+		if _, err := p.Call("Page.setLifecycleEventsEnabled", map[string]interface{}{"enabled": true}); err != nil {
+			return err
+		}
+	}
+	if _, err := p.Call("Runtime.enable", nil); err != nil {
+		return err
+	}
+	//开启后页面崩溃或调试连接被分离时会触发Inspector.targetCrashed、Inspector.detached事件
+	_, err := p.Call("Inspector.enable", nil)
+	return err
+}
+
 /**
 关闭连接，关闭连接后，将无法对标签进行操作
 传参：
@@ -85,10 +120,20 @@ func (p *Tag) Connect() (bool, error) {
 */
 func (p *Tag) Close(tagClose bool) {
 	p.taskLock.Lock()
-	p.ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-	p.ws.Close()
-	p.connect = false
+	s := p.session
 	p.taskLock.Unlock()
+	if s != nil && !s.isClosed() {
+		if s.id == "" {
+			s.conn.close()
+			//监听协程退出前会话仍显示为连接中，立即结束会话，使随后的Connect重新连接
+			s.conn.removeSession(s.id)
+		} else {
+			//附加的会话只分离本标签，不影响浏览器连接
+			p.browser.Call("Target.detachFromTarget", map[string]interface{}{"sessionId": s.id})
+			s.conn.removeSession(s.id)
+			p.browser.tags.Delete(p)
+		}
+	}
 	if tagClose {
 		big.HttpSend(&big.HttpParms{Url: "http://" + big.StrGetSub(p.WebSocketDebuggerUrl, "ws://", "/") + "/json/close/" + p.Id})
 	}
@@ -102,25 +147,35 @@ func (p *Tag) Close(tagClose bool) {
 	timeOut：等待页面加载完成时间，单位秒，为0表示不等待
 	flag：本参数在timeOut大于0时生效，如果flag为空字符串，则会等待到页面转圈结束为止，如果flag有值，则会等待到网页源码存在该值为止
 返回：
-	成功返回true，否则返回false，注意：当需等待网页加载完成时，返回的true和false代表网页是否加载完成。
+	成功返回nil，导航失败返回*NavigateError，浏览器返回错误时返回*CDPError，等待网页加载完成超时返回ErrLoadTimeout
 */
-func (p *Tag) TagJump(url string, referer string, timeOut int, flag string) bool {
+func (p *Tag) TagJump(url string, referer string, timeOut int, flag string) error {
 	parm := make(map[string]interface{})
 	parm["url"] = url
 	if referer != "" {
 		parm["referer"] = referer
 	}
-	p.readyState.loadEventFired = false
-	p.readyState.frameStartedLoading = false
-	p.readyState.frameStoppedLoading = false
 	res, err := p.Call("Page.navigate", parm)
-	if res == "" || err != nil {
-		return false
+	if err != nil {
+		return err
+	}
+	errorText := big.StrGetSub(res, "\"errorText\":\"", "\"")
+	if errorText != "" {
+		return &NavigateError{Url: url, ErrorText: errorText}
 	}
-	if timeOut > 0 {
-		return p.TagLoadWaitEnd(timeOut, flag)
+	if timeOut <= 0 {
+		return nil
 	}
-	return true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeOut)*time.Second)
+	defer cancel()
+	//页面内跳转（锚点）不会返回loaderId，也不会产生新文档
+	loaderId := big.StrGetSub(res, "\"loaderId\":\"", "\"")
+	if flag == "" && loaderId == "" {
+		return nil
+	}
+	return p.loadWait(ctx, flag, func() error {
+		return p.waitLoader(ctx, loaderId, LifecycleLoad)
+	})
 }
 
 /**
@@ -131,37 +186,42 @@ func (p *Tag) TagJump(url string, referer string, timeOut int, flag string) bool
 	timeOut：等待页面加载完成时间，单位秒，为0表示不等待
 	flag：本参数在timeOut大于0时生效，如果flag为空字符串，则会等待到页面转圈结束为止，如果flag有值，则会等待到网页源码存在该值为止
 返回：
-	成功返回true，否则返回false，注意：当需等待网页加载完成时，返回的true和false代表网页是否加载完成。
+	成功返回nil，失败返回error错误信息，等待网页加载完成超时返回ErrLoadTimeout
 */
-func (p *Tag) ReLoad(ignoreCache bool, scriptToEvaluateOnLoad string, timeOut int, flag string) bool {
+func (p *Tag) ReLoad(ignoreCache bool, scriptToEvaluateOnLoad string, timeOut int, flag string) error {
 	parm := make(map[string]interface{})
 	parm["ignoreCache"] = ignoreCache
 	if scriptToEvaluateOnLoad != "" {
 		parm["scriptToEvaluateOnLoad"] = scriptToEvaluateOnLoad
 	}
-	res, err := p.Call("Page.reload", parm)
-	if res == "" || err != nil {
-		return false
+	oldLoaderId := p.lifecycle.loaderId(p.Id)
+	_, err := p.Call("Page.reload", parm)
+	if err != nil {
+		return err
 	}
-	if timeOut > 0 {
-		return p.TagLoadWaitEnd(timeOut, flag)
+	if timeOut <= 0 {
+		return nil
 	}
-	return true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeOut)*time.Second)
+	defer cancel()
+	return p.loadWait(ctx, flag, func() error {
+		return p.waitReplaced(ctx, oldLoaderId, LifecycleLoad)
+	})
 }
 
 /**
 更新标签中的框架信息，当页面结构发生改变，应先调用本方法更新框架信息后才可执行后续操作
 返回：
-	返回是否成功，更新成功后调用本对象的.Frames属性获取框架资源信息
+	成功返回nil，失败返回error错误信息，更新成功后调用本对象的.Frames属性获取框架资源信息
 */
-func (p *Tag) TagFrameUpdate() bool {
+func (p *Tag) TagFrameUpdate() error {
 	res, err := p.Call("Page.getResourceTree", nil)
-	if res == "" || err != nil {
-		return false
+	if err != nil {
+		return err
 	}
 	jsonobj, err := simplejson.NewJson([]byte(res))
 	if err != nil {
-		return false
+		return err
 	}
 	//清空框架信息
 	p.Frames = make([]frame, 0)
@@ -187,7 +247,7 @@ func (p *Tag) TagFrameUpdate() bool {
 		json.Unmarshal(jbyte, &fra)
 		p.Frames = append(p.Frames, fra)
 	}
-	return true
+	return nil
 }
 
 /**
@@ -196,7 +256,10 @@ func (p *Tag) TagFrameUpdate() bool {
 	标签对象加载完成返回true，否则返回false
 */
 func (p *Tag) TagLoadIsEnd() bool {
-	return p.readyState.loadEventFired && p.readyState.frameStartedLoading && p.readyState.frameStoppedLoading
+	p.lifecycle.lock.Lock()
+	defer p.lifecycle.lock.Unlock()
+	f := p.lifecycle.frames[p.Id]
+	return f != nil && f.events[LifecycleLoad]
 }
 
 /**
@@ -208,22 +271,29 @@ func (p *Tag) TagLoadIsEnd() bool {
 	页面加载完成返回true，否则返回false
 */
 func (p *Tag) TagLoadWaitEnd(timeOut int, flag string) bool {
-	time.Sleep(1 * time.Second)
-	timeOut--
-	for i := 1; i <= timeOut; i++ {
-		if flag == "" && p.TagLoadIsEnd() {
-			time.Sleep(1 * time.Second)
-			return true
-		} else if flag != "" && p.TagFrameUpdate() {
-			res, _ := p.EvalJs("document.getElementsByTagName('html')[0].innerHTML.indexOf('"+flag+"')", 0)
-			if res.Value != "-1" {
-				time.Sleep(1 * time.Second)
-				return true
-			}
-		}
-		time.Sleep(1 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeOut)*time.Second)
+	defer cancel()
+	return p.loadWait(ctx, flag, func() error {
+		return p.WaitLifecycle(ctx, LifecycleLoad)
+	}) == nil
+}
+
+/**
+等待页面加载完成，flag为空时调用waitLoad等待，否则等待网页源码中出现flag
+返回：
+	成功返回nil，超时返回ErrLoadTimeout
+*/
+func (p *Tag) loadWait(ctx context.Context, flag string, waitLoad func() error) error {
+	var err error
+	if flag == "" {
+		err = waitLoad()
+	} else {
+		_, err = p.WaitForFunction(ctx, "document.documentElement.innerHTML.indexOf("+jsQuote(flag)+") != -1", 0)
 	}
-	return p.TagLoadIsEnd()
+	if err == context.DeadlineExceeded {
+		return ErrLoadTimeout
+	}
+	return err
 }
 
 /**
@@ -231,14 +301,14 @@ func (p *Tag) TagLoadWaitEnd(timeOut int, flag string) bool {
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
 返回：
-	网页源码
+	网页源码，失败时error返回具体信息
 */
-func (p *Tag) TagHtmlGet(contextId int) string {
-	res, err := p.EvalJs("document.getElementsByTagName('html')[0].innerHTML", contextId)
+func (p *Tag) TagHtmlGet(contextId int) (string, error) {
+	res, err := p.evalJs("document.getElementsByTagName('html')[0].innerHTML", contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
@@ -248,9 +318,9 @@ func (p *Tag) TagHtmlGet(contextId int) string {
 	url：本对象.Frames[0].Resources[]结构体中的url
 	base64：是否base64编码，如提取图片音视频则建议base64编码
 返回：
-	提取的结果
+	提取的结果，失败时error返回具体信息
 */
-func (p *Tag) TagResourceContentGet(frameId string, url string, base64 bool) string {
+func (p *Tag) TagResourceContentGet(frameId string, url string, base64 bool) (string, error) {
 	if frameId == "" {
 		frameId = p.Frames[0].Id
 	}
@@ -258,19 +328,19 @@ func (p *Tag) TagResourceContentGet(frameId string, url string, base64 bool) str
 	parm["frameId"] = frameId
 	parm["url"] = url
 	res, err := p.Call("Page.getResourceContent", parm)
-	if res == "" || err != nil {
-		return ""
+	if err != nil {
+		return "", err
 	}
 	sjson, err := simplejson.NewJson([]byte(res))
 	if err != nil {
-		return ""
+		return "", err
 	}
 	sjson = sjson.Get("result")
 	content, _ := sjson.Get("content").String()
 	if !strings.Contains(res, "base64Encoded\":true") && base64 {
 		content = big.EnCodeBase64Str(content)
 	}
-	return content
+	return content, nil
 }
 
 /**
@@ -289,6 +359,38 @@ func (p *Tag) EvalJs(jscode string, contextId int) (EvalRes, error) {
 	return p.Evaluate(jscode, "ChromeRemoteObject00000000000000000000000000000000", true, true, contextId, true, false, false, false)
 }
 
+/**
+执行JS代码，与EvalJs不同的是JS抛出异常时error返回*JsError，供Dom、Css系列方法使用
+传参：
+	jscode：JS代码，可以多行。
+	contextId：指定框架上下文id，传0表示默认主框架
+*/
+func (p *Tag) evalJs(jscode string, contextId int) (EvalRes, error) {
+	res, err := p.EvalJs(jscode, contextId)
+	if err != nil {
+		return res, err
+	}
+	if res.ExceptionDetails != "" && res.ExceptionDetails != "null" {
+		return res, newJsError(res.ExceptionDetails)
+	}
+	return res, nil
+}
+
+/**
+根据Runtime域返回的exceptionDetails生成*JsError
+*/
+func newJsError(details string) *JsError {
+	jsErr := &JsError{Details: details}
+	sjson, err := simplejson.NewJson([]byte(details))
+	if err == nil {
+		jsErr.Text, _ = sjson.Get("exception").Get("description").String()
+		if jsErr.Text == "" {
+			jsErr.Text, _ = sjson.Get("text").String()
+		}
+	}
+	return jsErr
+}
+
 /**
 拦截标签页对话框事件，当标签页弹出或关闭对话框(alert,confirm,prompt,beforeunload)时自动触发；
 若想取消拦截，则再次调用本方法，dopen和dclose参数传nil即可取消拦截
@@ -317,19 +419,16 @@ func (p *Tag) TagDialogIsHave() bool {
 	accept：接收或解除，true为接收对话框，false为解除对话框
 	promptText：输入文本，可空
 返回：
-	成功返回true，失败返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) TagDialogHandle(accept bool, promptText string) bool {
+func (p *Tag) TagDialogHandle(accept bool, promptText string) error {
 	parm := make(map[string]interface{})
 	parm["accept"] = accept
 	if promptText != "" {
 		parm["promptText"] = promptText
 	}
-	res, err := p.Call("Page.handleJavaScriptDialog", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Page.handleJavaScriptDialog", parm)
+	return err
 }
 
 /**
@@ -337,16 +436,13 @@ CPU限速：启用CPU限制来模拟缓慢的CPU
 传参：
 	rate：节流率为减速因子（1为无油门，2为2倍减速等）
 返回：
-	成功返回true，失败返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) TagCPUThrottlingRateSet(rate float64) bool {
+func (p *Tag) TagCPUThrottlingRateSet(rate float64) error {
 	parm := make(map[string]interface{})
 	parm["rate"] = rate
-	res, err := p.Call("Emulation.setCPUThrottlingRate", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Emulation.setCPUThrottlingRate", parm)
+	return err
 }
 
 /**
@@ -356,18 +452,15 @@ func (p *Tag) TagCPUThrottlingRateSet(rate float64) bool {
 	latitude：纬度
 	accuracy：精度
 返回：
-	设置成功返回true，否则返回false
+	设置成功返回nil，失败返回error错误信息
 */
-func (p *Tag) TagGeolocationOverrideSet(longitude float64, latitude float64, accuracy float64) bool {
+func (p *Tag) TagGeolocationOverrideSet(longitude float64, latitude float64, accuracy float64) error {
 	parm := make(map[string]interface{})
 	parm["longitude"] = longitude
 	parm["latitude"] = latitude
 	parm["accuracy"] = accuracy
-	res, err := p.Call("Emulation.setGeolocationOverride", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Emulation.setGeolocationOverride", parm)
+	return err
 }
 
 /**
@@ -375,20 +468,17 @@ func (p *Tag) TagGeolocationOverrideSet(longitude float64, latitude float64, acc
 传参：
 	ua：需要设置的UserAgent
 返回：
-	设置成功返回true，否则返回false
+	设置成功返回nil，失败返回error错误信息
 */
-func (p *Tag) TagUserAgentSet(ua string) bool {
+func (p *Tag) TagUserAgentSet(ua string) error {
 	parm := make(map[string]interface{})
 	parm["userAgent"] = ua
-	res, err := p.Call("Network.setUserAgentOverride", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Network.setUserAgentOverride", parm)
+	return err
 }
 
 /**
-网页快照截图，拍摄一张当前页面的渲染图像
+网页快照截图，拍摄一张当前页面的渲染图像，如需直接取得图片内容、截取整个页面或指定元素请使用Screenshot
 传参：
 	format：存储格式jpeg或png
 	quality：压缩质量，仅为jpeg格式下有效，取值范围0-100
@@ -398,9 +488,9 @@ func (p *Tag) TagUserAgentSet(ua string) bool {
 	width：截图指定区域的宽度，x、y、width、height均为0表示截取全网页
 	height：截图指定区域的高度，x、y、width、height均为0表示截取全网页
 返回：
-	成功返回base64编码后的图片内容，失败返回空文本
+	成功返回base64编码后的图片内容，失败返回空文本和error错误信息
 */
-func (p *Tag) TagCaptureScreenshot(format string, quality int, fromSurface bool, x int, y int, width int, height int) string {
+func (p *Tag) TagCaptureScreenshot(format string, quality int, fromSurface bool, x int, y int, width int, height int) (string, error) {
 	parm := make(map[string]interface{})
 	parm["format"] = format
 	parm["quality"] = quality
@@ -415,10 +505,10 @@ func (p *Tag) TagCaptureScreenshot(format string, quality int, fromSurface bool,
 		}
 	}
 	res, err := p.Call("Page.captureScreenshot", parm)
-	if res == "" || err != nil {
-		return ""
+	if err != nil {
+		return "", err
 	}
-	return big.StrGetSub(res, "{\"data\":\"", "\"}}")
+	return big.StrGetSub(res, "{\"data\":\"", "\"}}"), nil
 }
 
 /**
@@ -427,36 +517,33 @@ func (p *Tag) TagCaptureScreenshot(format string, quality int, fromSurface bool,
 	enable：是否启用仿真触摸事件，true为启用，false为不启用
 	configuration：手势事件类型，可选值mobile, desktop
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) TagTouchEmulationEnabledSet(enable bool, configuration string) bool {
+func (p *Tag) TagTouchEmulationEnabledSet(enable bool, configuration string) error {
 	parm := make(map[string]interface{})
 	parm["enabled"] = enable
 	parm["configuration"] = configuration
-	res, err := p.Call("Emulation.setEmitTouchEventsForMouse", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Emulation.setEmitTouchEventsForMouse", parm)
+	return err
 }
 
 /**
 清除缓存
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) TagCacheClear() bool {
+func (p *Tag) TagCacheClear() error {
 	//先判断浏览器是否支持清除缓存
 	res, err := p.Call("Network.canClearBrowserCache", nil)
+	if err != nil {
+		return err
+	}
 	if !strings.Contains(res, "true") {
 		//不支持本操作
-		return false
-	}
-	res, err = p.Call("Network.clearBrowserCache", nil)
-	if res == "" || err != nil {
-		return false
+		return ErrUnsupported
 	}
-	return true
+	_, err = p.Call("Network.clearBrowserCache", nil)
+	return err
 }
 
 /**
@@ -493,9 +580,9 @@ window.innerHeight和“device-width”/“device-height”）相关的CSS媒体
 	screenOrientationType：屏幕方向类型，设置屏幕方向,可空,可选的值: portraitPrimary, portraitSecondary, landscapePrimary, landscapeSecondary
 	screenOrientationAngle：屏幕角度，所处方向的角度
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) TagScreenSet(width int, height int, deviceScaleFactor float64, mobile bool, flScale float64, screenWidth int, screenHeight int, positionX int, positionY int, screenOrientationType string, screenOrientationAngle int) bool {
+func (p *Tag) TagScreenSet(width int, height int, deviceScaleFactor float64, mobile bool, flScale float64, screenWidth int, screenHeight int, positionX int, positionY int, screenOrientationType string, screenOrientationAngle int) error {
 	parm := make(map[string]interface{})
 	parm["width"] = width
 	parm["height"] = height
@@ -513,24 +600,18 @@ func (p *Tag) TagScreenSet(width int, height int, deviceScaleFactor float64, mob
 		screenOrientation["type"] = screenOrientationType
 	}
 	parm["screenOrientation"] = screenOrientation
-	res, err := p.Call("Emulation.setDeviceMetricsOverride", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Emulation.setDeviceMetricsOverride", parm)
+	return err
 }
 
 /**
 清除设置屏幕的指标值
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) TagScreenClear() bool {
-	res, err := p.Call("Emulation.clearDeviceMetricsOverride", nil)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+func (p *Tag) TagScreenClear() error {
+	_, err := p.Call("Emulation.clearDeviceMetricsOverride", nil)
+	return err
 }
 
 /**
@@ -542,9 +623,9 @@ func (p *Tag) TagScreenClear() bool {
 	height：设置浏览器高度，单位px，传-1表示不设置
 	windowState：窗口状态，可选值normal（普通）, minimized（最小化）, maximized（最大化）, fullscreen（全屏），传空字符串表示不设置
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) WindowSet(left int, top int, width int, height int, windowState string) bool {
+func (p *Tag) WindowSet(left int, top int, width int, height int, windowState string) error {
 	parm := make(map[string]interface{})
 	bounds := make(map[string]interface{})
 	if left != -1 {
@@ -564,11 +645,8 @@ func (p *Tag) WindowSet(left int, top int, width int, height int, windowState st
 	}
 	parm["windowId"] = 1
 	parm["bounds"] = bounds
-	res, err := p.Call("Browser.setWindowBounds", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Browser.setWindowBounds", parm)
+	return err
 }
 
 /**
@@ -578,12 +656,9 @@ func (p *Tag) WindowSet(left int, top int, width int, height int, windowState st
 	xNum：文档向右滚动的像素数
 	yNum：文档向下滚动的像素数
 */
-func (p *Tag) WindowScrollBySet(contextId int, xNum int, yNum int) bool {
-	_, err := p.EvalJs(fmt.Sprintf("window.scrollBy(%d,%d)", xNum, yNum), contextId)
-	if err != nil {
-		return false
-	}
-	return true
+func (p *Tag) WindowScrollBySet(contextId int, xNum int, yNum int) error {
+	_, err := p.evalJs(fmt.Sprintf("window.scrollBy(%d,%d)", xNum, yNum), contextId)
+	return err
 }
 
 /**
@@ -593,31 +668,28 @@ func (p *Tag) WindowScrollBySet(contextId int, xNum int, yNum int) bool {
 	x：要在窗口文档显示区左上角显示的文档的x坐标
 	y：要在窗口文档显示区左上角显示的文档的y坐标
 */
-func (p *Tag) WindowScrollToSet(contextId int, x int, y int) bool {
-	_, err := p.EvalJs(fmt.Sprintf("window.scrollTo(%d,%d)", x, y), contextId)
-	if err != nil {
-		return false
-	}
-	return true
+func (p *Tag) WindowScrollToSet(contextId int, x int, y int) error {
+	_, err := p.evalJs(fmt.Sprintf("window.scrollTo(%d,%d)", x, y), contextId)
+	return err
 }
 
 /**
 Cookies清空，清除当前页面中Cookies数据
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) CookiesClear() bool {
+func (p *Tag) CookiesClear() error {
 	//先判断浏览器是否支持清除缓存
 	res, err := p.Call("Network.canClearBrowserCookies", nil)
+	if err != nil {
+		return err
+	}
 	if !strings.Contains(res, "true") {
 		//不支持本操作
-		return false
+		return ErrUnsupported
 	}
-	res, err = p.Call("Network.clearBrowserCookies", nil)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err = p.Call("Network.clearBrowserCookies", nil)
+	return err
 }
 
 /**
@@ -671,35 +743,46 @@ Cookies删除单个
 	url：domain+Path的字符串拼接，也可以只传domain
 	name：名称，要删除的Cookie名称
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) CookiesDel(url string, name string) bool {
+func (p *Tag) CookiesDel(url string, name string) error {
 	parm := make(map[string]interface{})
 	parm["url"] = url
 	parm["name"] = name
-	res, err := p.Call("Network.deleteCookies", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Network.deleteCookies", parm)
+	return err
 }
 
 /**
 Cookies单个设置，如不存在，则创建
 传参：
-	cookie：欲设置的Cookie，请使用chrome.Cookie赋值并传入
+	cookie：欲设置的Cookie，请使用chrome.Cookie赋值并传入，CookiesGet、CookiesFromNetscape等取到的Cookie可直接传入
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) CookiesSet(cookie Cookie) bool {
+func (p *Tag) CookiesSet(cookie Cookie) error {
+	cookie = normalizeCookie(cookie)
 	parm := make(map[string]interface{})
 	bres, _ := json.Marshal(&cookie)
 	json.Unmarshal(bres, &parm)
+	//空值表示未设置，传空文本浏览器会报参数错误
+	for _, k := range []string{"domain", "url", "priority", "sameSite"} {
+		if parm[k] == "" {
+			delete(parm, k)
+		}
+	}
+	//不传过期时间才是会话Cookie，传0或-1都会被当作已过期
+	if cookie.Session || cookie.Expires <= 0 {
+		delete(parm, "expires")
+	}
 	res, err := p.Call("Network.setCookie", parm)
-	if !strings.Contains(res, "true") || err != nil {
-		return false
+	if err != nil {
+		return err
 	}
-	return true
+	if !strings.Contains(res, "true") {
+		return ErrCookieRejected
+	}
+	return nil
 }
 
 /**
@@ -713,9 +796,9 @@ Cookies批量设置【文本形式】
 	httponly：是否禁止通过JS获取该Cookie，true为禁止，false为允许
 	cookies：字符串格式的cookies，类似于：name=value; name=value这种格式
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) CookiesSetStr(url string, domain string, path string, secure bool, expires float64, httponly bool, cookies string) bool {
+func (p *Tag) CookiesSetStr(url string, domain string, path string, secure bool, expires float64, httponly bool, cookies string) error {
 	cookies = strings.TrimSpace(cookies)
 	arr := strings.Split(cookies, "; ")
 	for _, v := range arr {
@@ -737,11 +820,11 @@ func (p *Tag) CookiesSetStr(url string, domain string, path string, secure bool,
 			Url:      url,
 			SameSite: "Lax",
 		}
-		if !p.CookiesSet(cookie) {
-			return false
+		if err := p.CookiesSet(cookie); err != nil {
+			return err
 		}
 	}
-	return true
+	return nil
 }
 
 /**
@@ -749,310 +832,283 @@ Cookies批量设置【[]chrome.Cookie结构体切片形式】
 传参：
 	cookies：[]chrome.Cookie结构体切片
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) CookiesSetStu(cookies []Cookie) bool {
+func (p *Tag) CookiesSetStu(cookies []Cookie) error {
 	for _, v := range cookies {
-		if !p.CookiesSet(v) {
-			return false
+		if err := p.CookiesSet(v); err != nil {
+			return err
 		}
 	}
-	return true
+	return nil
 }
 
 /**
 CSS取表单（FORM）提交地址
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query）
 返回：
-	结果
+	结果，失败时error返回具体信息
 */
-func (p *Tag) CssFormUrlGet(contextId int, selector string) string {
+func (p *Tag) CssFormUrlGet(contextId int, selector string) (string, error) {
 	if contextId < 0 || selector == "" {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').action", selector), contextId)
+	res, err := p.evalJs(querySelectorJs(selector)+".action", contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 CSS置表单（FORM）提交地址
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query）
 	url：欲设置的表单提交地址
 返回：
-	设置成功，返回设置的url表单提交地址
+	设置成功，返回设置的url表单提交地址，失败时error返回具体信息
 */
-func (p *Tag) CssFormUrlSet(contextId int, selector string, url string) string {
+func (p *Tag) CssFormUrlSet(contextId int, selector string, url string) (string, error) {
 	if contextId < 0 || selector == "" || url == "" {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').action='%s'", selector, url), contextId)
+	res, err := p.evalJs(querySelectorJs(selector)+".action="+jsQuote(url), contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 CSS表单（FORM）重置
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query）
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) CssFormReset(contextId int, selector string) bool {
+func (p *Tag) CssFormReset(contextId int, selector string) error {
 	if contextId < 0 || selector == "" {
-		return false
-	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').reset()", selector), contextId)
-	if err != nil {
-		return false
+		return ErrInvalidParam
 	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".reset()", contextId)
+	return err
 }
 
 /**
 CSS表单（FORM）提交
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query）
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) CssFormSubmit(contextId int, selector string) bool {
+func (p *Tag) CssFormSubmit(contextId int, selector string) error {
 	if contextId < 0 || selector == "" {
-		return false
-	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').submit()", selector), contextId)
-	if err != nil {
-		return false
+		return ErrInvalidParam
 	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".submit()", contextId)
+	return err
 }
 
 /**
 CSS下拉菜单（SELECT）取表项文本
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	index：表项下标
 返回：
-	结果
+	结果，失败时error返回具体信息
 */
-func (p *Tag) CssSelectTextGet(contextId int, selector string, index int) string {
+func (p *Tag) CssSelectTextGet(contextId int, selector string, index int) (string, error) {
 	if contextId < 0 || selector == "" || index == -1 {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').options[%d].text", selector, index), contextId)
+	res, err := p.evalJs(fmt.Sprintf("%s.options[%d].text", querySelectorJs(selector), index), contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 CSS下拉菜单（SELECT）取表项数
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	表项总数
+	表项总数，失败时error返回具体信息
 */
-func (p *Tag) CssSelectIndexLength(contextId int, selector string) int {
+func (p *Tag) CssSelectIndexLength(contextId int, selector string) (int, error) {
 	if contextId < 0 || selector == "" {
-		return 0
-	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').length", selector), contextId)
-	if err != nil {
-		return 0
+		return 0, ErrInvalidParam
 	}
-	ok, err := strconv.Atoi(res.Value)
+	res, err := p.evalJs(querySelectorJs(selector)+".length", contextId)
 	if err != nil {
-		return 0
+		return 0, err
 	}
-	return ok
+	return strconv.Atoi(res.Value)
 }
 
 /**
 CSS下拉菜单（SELECT）取现行选中项
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	现在选中的表项下标
+	现在选中的表项下标，失败时error返回具体信息
 */
-func (p *Tag) CssSelectNowIndexGet(contextId int, selector string) int {
+func (p *Tag) CssSelectNowIndexGet(contextId int, selector string) (int, error) {
 	if contextId < 0 || selector == "" {
-		return 0
-	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').selectedIndex", selector), contextId)
-	if err != nil {
-		return 0
+		return 0, ErrInvalidParam
 	}
-	ok, err := strconv.Atoi(res.Value)
+	res, err := p.evalJs(querySelectorJs(selector)+".selectedIndex", contextId)
 	if err != nil {
-		return 0
+		return 0, err
 	}
-	return ok
+	return strconv.Atoi(res.Value)
 }
 
 /**
 CSS下拉菜单（SELECT）置现行选中项
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	index：欲设置的选中项下标
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) CssSelectNowIndexSet(contextId int, selector string, index int) bool {
+func (p *Tag) CssSelectNowIndexSet(contextId int, selector string, index int) error {
 	if contextId < 0 || selector == "" {
-		return false
+		return ErrInvalidParam
 	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').selectedIndex=%d", selector, index), contextId)
-	if err != nil {
-		return false
-	}
-	return true
+	_, err := p.evalJs(fmt.Sprintf("%s.selectedIndex=%d", querySelectorJs(selector), index), contextId)
+	return err
 }
 
 /**
 CSS表格（Table）取单元格文本
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	row：第几行
 	cell：第几列
 返回：
-	单元格文本
+	单元格文本，失败时error返回具体信息
 */
-func (p *Tag) CssTableCellTextGet(contextId int, selector string, row int, cell int) string {
+func (p *Tag) CssTableCellTextGet(contextId int, selector string, row int, cell int) (string, error) {
 	if contextId < 0 || selector == "" || row == -1 || cell == -1 {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').rows[%d].cells[%d].innerText", selector, row, cell), contextId)
+	res, err := p.evalJs(fmt.Sprintf("%s.rows[%d].cells[%d].innerText", querySelectorJs(selector), row, cell), contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 CSS表格（Table）取单元格源码
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	row：第几行
 	cell：第几列
 返回：
-	单元格源码
+	单元格源码，失败时error返回具体信息
 */
-func (p *Tag) CssTableCellHtmlGet(contextId int, selector string, row int, cell int) string {
+func (p *Tag) CssTableCellHtmlGet(contextId int, selector string, row int, cell int) (string, error) {
 	if contextId < 0 || selector == "" || row == -1 || cell == -1 {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').rows[%d].cells[%d].innerHTML", selector, row, cell), contextId)
+	res, err := p.evalJs(fmt.Sprintf("%s.rows[%d].cells[%d].innerHTML", querySelectorJs(selector), row, cell), contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 CSS表格（Table）取单元格行数
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	单元格行数
+	单元格行数，失败时error返回具体信息
 */
-func (p *Tag) CssTableRows(contextId int, selector string) int {
+func (p *Tag) CssTableRows(contextId int, selector string) (int, error) {
 	if contextId < 0 || selector == "" {
-		return 0
-	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').rows.length", selector), contextId)
-	if err != nil {
-		return 0
+		return 0, ErrInvalidParam
 	}
-	ok, err := strconv.Atoi(res.Value)
+	res, err := p.evalJs(querySelectorJs(selector)+".rows.length", contextId)
 	if err != nil {
-		return 0
+		return 0, err
 	}
-	return ok
+	return strconv.Atoi(res.Value)
 }
 
 /**
 CSS表格（Table）取单元格列数
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	单元格列数
+	单元格列数，失败时error返回具体信息
 */
-func (p *Tag) CssTableCells(contextId int, selector string) int {
+func (p *Tag) CssTableCells(contextId int, selector string) (int, error) {
 	if contextId < 0 || selector == "" {
-		return 0
+		return 0, ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').cells.length", selector), contextId)
+	res, err := p.evalJs(querySelectorJs(selector)+".cells.length", contextId)
 	if err != nil {
-		return 0
+		return 0, err
 	}
-	ok, err := strconv.Atoi(res.Value)
-	if err != nil {
-		return 0
-	}
-	return ok
+	return strconv.Atoi(res.Value)
 }
 
 /**
-元素触发单击事件
+元素触发单击事件，本方法调用JS的element.click()，不产生真实鼠标事件，如需模拟真实点击请使用ClickElement
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) DomClick(contextId int, selector string) bool {
+func (p *Tag) DomClick(contextId int, selector string) error {
 	if contextId < 0 || selector == "" {
-		return false
-	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').click()", selector), contextId)
-	if err != nil {
-		return false
+		return ErrInvalidParam
 	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".click()", contextId)
+	return err
 }
 
 /**
 元素定位
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
 	x：元素x坐标
 	y：元素y坐标
+	err：失败时返回error错误信息
 */
-func (p *Tag) DomPosition(contextId int, selector string) (x int, y int) {
+func (p *Tag) DomPosition(contextId int, selector string) (x int, y int, err error) {
 	if contextId < 0 || selector == "" {
-		return 0, 0
+		return 0, 0, ErrInvalidParam
 	}
 	const jscode string = `function taptap(){
-var n = document.querySelector("%s").getBoundingClientRect();
+var n = %s.getBoundingClientRect();
 var x = (n.left + document.documentElement.scrollLeft).toFixed();
 var y = (n.top + document.documentElement.scrollTop).toFixed();
 return x+","+y;
 }
 taptap();`
-	res, err := p.EvalJs(fmt.Sprintf(jscode, selector), contextId)
+	res, err := p.evalJs(fmt.Sprintf(jscode, querySelectorJs(selector)), contextId)
 	if err != nil {
-		return 0, 0
+		return 0, 0, err
 	}
 	res.Value = strings.ReplaceAll(res.Value, "\"", "")
 	resArr := strings.Split(res.Value, ",")
@@ -1065,240 +1121,219 @@ taptap();`
 元素焦点激活
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) DomFocus(contextId int, selector string) bool {
+func (p *Tag) DomFocus(contextId int, selector string) error {
 	if contextId < 0 || selector == "" {
-		return false
+		return ErrInvalidParam
 	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').focus()", selector), contextId)
-	if err != nil {
-		return false
-	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".focus()", contextId)
+	return err
 }
 
 /**
 元素焦点失去
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) DomBlur(contextId int, selector string) bool {
+func (p *Tag) DomBlur(contextId int, selector string) error {
 	if contextId < 0 || selector == "" {
-		return false
-	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').blur()", selector), contextId)
-	if err != nil {
-		return false
+		return ErrInvalidParam
 	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".blur()", contextId)
+	return err
 }
 
 /**
 元素取HTML
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	HTML源码
+	HTML源码，失败时error返回具体信息
 */
-func (p *Tag) DomHtmlGet(contextId int, selector string) string {
+func (p *Tag) DomHtmlGet(contextId int, selector string) (string, error) {
 	if contextId < 0 || selector == "" {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').innerHTML", selector), contextId)
+	res, err := p.evalJs(querySelectorJs(selector)+".innerHTML", contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 元素置HTML
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	html：欲设置的html源码
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) DomHtmlSet(contextId int, selector string, html string) bool {
+func (p *Tag) DomHtmlSet(contextId int, selector string, html string) error {
 	if contextId < 0 || selector == "" {
-		return false
+		return ErrInvalidParam
 	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').innerHTML='%s'", selector, html), contextId)
-	if err != nil {
-		return false
-	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".innerHTML="+jsQuote(html), contextId)
+	return err
 }
 
 /**
 元素取属性值
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	name：属性名
 返回：
-	结果
+	结果，失败时error返回具体信息
 */
-func (p *Tag) DomAttributeGet(contextId int, selector string, name string) string {
+func (p *Tag) DomAttributeGet(contextId int, selector string, name string) (string, error) {
 	if contextId < 0 || selector == "" || name == "" {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').getAttribute('%s')", selector, name), contextId)
+	res, err := p.evalJs(querySelectorJs(selector)+".getAttribute("+jsQuote(name)+")", contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 元素置属性值
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	name：属性名
 	val：属性值
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) DomAttributeSet(contextId int, selector string, name string, val string) bool {
+func (p *Tag) DomAttributeSet(contextId int, selector string, name string, val string) error {
 	if contextId < 0 || selector == "" || name == "" || val == "" {
-		return false
-	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').setAttribute('%s','%s')", selector, name, val), contextId)
-	if err != nil {
-		return false
+		return ErrInvalidParam
 	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".setAttribute("+jsQuote(name)+","+jsQuote(val)+")", contextId)
+	return err
 }
 
 /**
 元素取文本
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	结果
+	结果，失败时error返回具体信息
 */
-func (p *Tag) DomTextGet(contextId int, selector string) string {
+func (p *Tag) DomTextGet(contextId int, selector string) (string, error) {
 	if contextId < 0 || selector == "" {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').innerText", selector), contextId)
+	res, err := p.evalJs(querySelectorJs(selector)+".innerText", contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 元素置文本
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	text：欲设置的元素文本
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) DomTextSet(contextId int, selector string, text string) bool {
+func (p *Tag) DomTextSet(contextId int, selector string, text string) error {
 	if contextId < 0 || selector == "" || text == "" {
-		return false
+		return ErrInvalidParam
 	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').innerText='%s'", selector, text), contextId)
-	if err != nil {
-		return false
-	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".innerText="+jsQuote(text), contextId)
+	return err
 }
 
 /**
 元素取值
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 返回：
-	结果
+	结果，失败时error返回具体信息
 */
-func (p *Tag) DomValGet(contextId int, selector string) string {
+func (p *Tag) DomValGet(contextId int, selector string) (string, error) {
 	if contextId < 0 || selector == "" {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').value", selector), contextId)
+	res, err := p.evalJs(querySelectorJs(selector)+".value", contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 元素置值
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	val：欲设置的元素值
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) DomValSet(contextId int, selector string, val string) bool {
+func (p *Tag) DomValSet(contextId int, selector string, val string) error {
 	if contextId < 0 || selector == "" || val == "" {
-		return false
-	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').value='%s'", selector, val), contextId)
-	if err != nil {
-		return false
+		return ErrInvalidParam
 	}
-	return true
+	_, err := p.evalJs(querySelectorJs(selector)+".value="+jsQuote(val), contextId)
+	return err
 }
 
 /**
 元素执行指定事件
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	name：欲执行的事件，需自己加括号，例如：click()
 返回：
-	执行返回结果
+	执行返回结果，失败时error返回具体信息
 */
-func (p *Tag) DomOnEvent(contextId int, selector string, name string) string {
+func (p *Tag) DomOnEvent(contextId int, selector string, name string) (string, error) {
 	if contextId < 0 || selector == "" || name == "" {
-		return ""
+		return "", ErrInvalidParam
 	}
-	res, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').%s", selector, name), contextId)
+	res, err := p.evalJs(querySelectorJs(selector)+"."+name, contextId)
 	if err != nil {
-		return ""
+		return "", err
 	}
-	return res.Value
+	return res.Value, nil
 }
 
 /**
 元素复选框置状态
 传参：
 	contextId：指定框架上下文id，传0表示默认主框架
-	selector：CSS选择器路径，用于选择SELECT标签
+	selector：选择器路径（支持CSS、XPath、文本匹配，格式见Query），用于选择SELECT标签
 	check：是否选中，true为选中，false为未选中
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) DomCheckboxSet(contextId int, selector string, check string) bool {
+func (p *Tag) DomCheckboxSet(contextId int, selector string, check string) error {
 	if contextId < 0 || selector == "" || check == "" {
-		return false
+		return ErrInvalidParam
 	}
-	_, err := p.EvalJs(fmt.Sprintf("document.querySelector('%s').checked=%s", selector, check), contextId)
-	if err != nil {
-		return false
-	}
-	return true
+	_, err := p.evalJs(fmt.Sprintf("%s.checked=%t", querySelectorJs(selector), check == "true"), contextId)
+	return err
 }
 
 /**
-发送按键事件
+发送按键事件，需自行传入功能键掩码和虚拟键代码，一般使用Press、KeyDown、KeyUp更方便
 传参：
 	typ：按键类型,可选值: keyDown, keyUp, rawKeyDown, char
 	modifiers：功能键，可选值：Alt = 1，Ctrl = 2，Meta/Command = 4，Shift = 8，默认为 = 0
@@ -1306,9 +1341,9 @@ func (p *Tag) DomCheckboxSet(contextId int, selector string, check string) bool
 	isKeypad：事件是否从小键盘生成，true为是，false为否，一般为false
 	isSysttemKey：事件是否是系统键事件，true为是，false为否，一般为false
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) InputSendKey(typ string, modifiers int, windowsVirtualKeyCode int, isKeypad bool, isSysttemKey bool) bool {
+func (p *Tag) InputSendKey(typ string, modifiers int, windowsVirtualKeyCode int, isKeypad bool, isSysttemKey bool) error {
 	return p.keyEvent(typ, modifiers, windowsVirtualKeyCode, "", isKeypad, isSysttemKey)
 }
 
@@ -1321,9 +1356,9 @@ func (p *Tag) InputSendKey(typ string, modifiers int, windowsVirtualKeyCode int,
 	isKeypad：事件是否从小键盘生成，true为是，false为否，一般为false
 	isSysttemKey：事件是否是系统键事件，true为是，false为否，一般为false
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) InputSendText(typ string, modifiers int, text string, isKeypad bool, isSysttemKey bool) bool {
+func (p *Tag) InputSendText(typ string, modifiers int, text string, isKeypad bool, isSysttemKey bool) error {
 	return p.keyEvent(typ, modifiers, 0, text, isKeypad, isSysttemKey)
 }
 
@@ -1339,9 +1374,9 @@ func (p *Tag) InputSendText(typ string, modifiers int, text string, isKeypad boo
 	deltaX：鼠标滚轮事件的CSS像素中的X delta（默认值：0）
 	deltaY：鼠标滚轮事件的CSS像素中的Y delta（默认值：0）
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) InputSendMouse(typ string, x int, y int, modifiers int, button string, clickCount int, deltaX int, deltaY int) bool {
+func (p *Tag) InputSendMouse(typ string, x int, y int, modifiers int, button string, clickCount int, deltaX int, deltaY int) error {
 	parm := make(map[string]interface{})
 	parm["type"] = typ
 	parm["x"] = x
@@ -1353,62 +1388,20 @@ func (p *Tag) InputSendMouse(typ string, x int, y int, modifiers int, button str
 	parm["clickCount"] = clickCount
 	parm["deltaX"] = deltaX
 	parm["deltaY"] = deltaY
-	res, err := p.Call("Input.dispatchMouseEvent", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Input.dispatchMouseEvent", parm)
+	return err
 }
 
 /**
-模拟鼠标轨迹移动到指定坐标
+模拟鼠标轨迹移动到指定坐标，轨迹由DefaultTrack生成，如需指定轨迹请使用InputMouseMoveTrack
 传参：
 	x：事件的X坐标相对于CSS像素中的主框架的视口
 	y：事件的Y坐标相对于CSS像素中的主框架视口，0表示视口的顶部，Y随着进入视口底部而增加。
+返回：
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) InputMouseMove(x int, y int) {
-	xArr := make([]int, 0)
-	yArr := make([]int, 0)
-	x = x - p.px
-	y = y - p.py
-	positiveX := true
-	positiveY := true
-	if x < 0 {
-		positiveX = false
-	}
-	if y < 0 {
-		positiveY = false
-	}
-	x = int(math.Abs(float64(x)))
-	y = int(math.Abs(float64(y)))
-	p.InputSendMouse("mouseMoved", x, y, 0, "none", 0, 0, 0)
-	for {
-		if x > 0 {
-			x--
-			if positiveX {
-				p.px++
-			} else {
-				p.px--
-			}
-		}
-
-		if y > 0 {
-			y--
-			if positiveY {
-				p.py++
-			} else {
-				p.py--
-			}
-		}
-		xArr = append(xArr, p.px)
-		yArr = append(yArr, p.py)
-		if x == 0 && y == 0 {
-			break
-		}
-	}
-	for i := 0; i < len(xArr); i++ {
-		p.InputSendMouse("mouseMoved", xArr[i], yArr[i], 0, "none", 0, 0, 0)
-	}
+func (p *Tag) InputMouseMove(x int, y int) error {
+	return p.InputMouseMoveTrack(x, y, nil)
 }
 
 /**
@@ -1418,20 +1411,17 @@ func (p *Tag) InputMouseMove(x int, y int) {
 	touchs：触摸设备上的活动触摸点。每个任何改变点（与序列中的先前触摸事件相比）产生一个事件，逐个模拟按压/移动/释放点。
 	modifiers：功能键，可选值：Alt = 1，Ctrl = 2，Meta/Command = 4，Shift = 8，默认为 = 0
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) InputSendTouch(typ string, touchs []Touch, modifiers int) bool {
+func (p *Tag) InputSendTouch(typ string, touchs []Touch, modifiers int) error {
 	_, timestamp := big.TimeStamp(10)
 	parm := make(map[string]interface{})
 	parm["type"] = typ
 	parm["touchPoints"] = touchs
 	parm["modifiers"] = modifiers
 	parm["timestamp"] = timestamp
-	res, err := p.Call("Input.dispatchTouchEvent", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Input.dispatchTouchEvent", parm)
+	return err
 }
 
 /**
@@ -1443,20 +1433,17 @@ func (p *Tag) InputSendTouch(typ string, touchs []Touch, modifiers int) bool {
 	relativeSpeed：相对指针速度（以像素为单位）（默认值：800）
 	gestureSourceType：要生成哪种类型的输入事件（默认值：“default”，它会查询平台的首选输入类型),可选值:default, touch, mouse
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) InputSendPinch(x float64, y float64, scaleFactor float64, relativeSpeed int, gestureSourceType string) bool {
+func (p *Tag) InputSendPinch(x float64, y float64, scaleFactor float64, relativeSpeed int, gestureSourceType string) error {
 	parm := make(map[string]interface{})
 	parm["x"] = x
 	parm["y"] = y
 	parm["scaleFactor"] = scaleFactor
 	parm["relativeSpeed"] = relativeSpeed
 	parm["gestureSourceType"] = gestureSourceType
-	res, err := p.Call("Input.synthesizePinchGesture", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Input.synthesizePinchGesture", parm)
+	return err
 }
 
 /**
@@ -1474,9 +1461,9 @@ func (p *Tag) InputSendPinch(x float64, y float64, scaleFactor float64, relative
 	repeatCount：重复次数，重复手势的次数（默认值：0）
 	repeatDelayMs：每次重复之间的毫秒数延迟（默认值：250）
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) InputSendRoll(x float64, y float64, xDistance float64, yDistance float64, xOverscroll float64, yOverscroll float64, preventFling bool, speed int, gestureSourceType string, repeatCount int, repeatDelayMs int) bool {
+func (p *Tag) InputSendRoll(x float64, y float64, xDistance float64, yDistance float64, xOverscroll float64, yOverscroll float64, preventFling bool, speed int, gestureSourceType string, repeatCount int, repeatDelayMs int) error {
 	parm := make(map[string]interface{})
 	parm["x"] = x
 	parm["y"] = y
@@ -1489,11 +1476,8 @@ func (p *Tag) InputSendRoll(x float64, y float64, xDistance float64, yDistance f
 	parm["gestureSourceType"] = gestureSourceType
 	parm["repeatCount"] = repeatCount
 	parm["repeatDelayMs"] = repeatDelayMs
-	res, err := p.Call("Input.synthesizeScrollGesture", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Input.synthesizeScrollGesture", parm)
+	return err
 }
 
 /**
@@ -1505,39 +1489,42 @@ func (p *Tag) InputSendRoll(x float64, y float64, xDistance float64, yDistance f
 	tapCount：Tap次数，执行Tap的次数（例如2次双击，默认值为1）
 	gestureSourceType：手势类型，要生成哪种类型的输入事件（默认值：“default”，它会查询平台的首选输入类型）,可选值:default, touch, mouse
 返回：
-	成功返回true，否则返回false
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) InputSendClick(x float64, y float64, duration int, tapCount int, gestureSourceType string) bool {
+func (p *Tag) InputSendClick(x float64, y float64, duration int, tapCount int, gestureSourceType string) error {
 	parm := make(map[string]interface{})
 	parm["x"] = x
 	parm["y"] = y
 	parm["duration"] = duration
 	parm["tapCount"] = tapCount
 	parm["gestureSourceType"] = gestureSourceType
-	res, err := p.Call("Input.synthesizeTapGesture", parm)
-	if res == "" || err != nil {
-		return false
-	}
-	return true
+	_, err := p.Call("Input.synthesizeTapGesture", parm)
+	return err
 }
 
 /**
-开启拦截网络请求，目前仅支持拦截不支持修改数据
+开启拦截网络请求，仅能查看请求和响应，如需修改、伪造或拦截请求请使用HookFetchEn
 传参：
 	req：传入格式为func(tag *chrome.Tag, request chrome.HookHttpRequest)的函数，当有请求时会自动触发
 	resp：传入格式为func(tag *chrome.Tag, response HookHttpResponse)的函数，当有响应时会自动触发
+返回：
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) HookHttpEn(req func(tag *Tag, request HookHttpRequest), resp func(tag *Tag, response HookHttpResponse)) {
+func (p *Tag) HookHttpEn(req func(tag *Tag, request HookHttpRequest), resp func(tag *Tag, response HookHttpResponse)) error {
 	p.hookReqEvent = req
 	p.hookRespEvent = resp
-	p.Call("Network.enable", nil)
+	_, err := p.Call("Network.enable", nil)
+	return err
 }
 
 /**
 禁用拦截网络请求
+返回：
+	成功返回nil，失败返回error错误信息
 */
-func (p *Tag) HookHttpDis() {
-	p.Call("Network.disable", nil)
+func (p *Tag) HookHttpDis() error {
+	_, err := p.Call("Network.disable", nil)
+	return err
 }
 
 /**
@@ -1545,16 +1532,16 @@ func (p *Tag) HookHttpDis() {
 传参：
 	requestId：该参数在拦截的网络请求形参中有，传入对应相同名的参数即可
 返回：
-	返回chrome.HookHttpBody结构体对象，具体字段属性含义查看源码定义时的注释
+	返回chrome.HookHttpBody结构体对象，具体字段属性含义查看源码定义时的注释，失败时error返回具体信息
 */
-func (p *Tag) HookGetBody(requestId string) HookHttpBody {
+func (p *Tag) HookGetBody(requestId string) (HookHttpBody, error) {
 	entity := HookHttpBody{}
 	parm := make(map[string]interface{})
 	parm["requestId"] = requestId
 	res, err := p.Call("Network.getResponseBody", parm)
-	if res == "" || err != nil {
-		return entity
+	if err != nil {
+		return entity, err
 	}
-	json.Unmarshal([]byte(res), &entity)
-	return entity
+	err = json.Unmarshal([]byte(res), &entity)
+	return entity, err
 }
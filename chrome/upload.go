@@ -0,0 +1,118 @@
+package chrome
+
+import (
+	"os"
+	"path/filepath"
+)
+
+/**
+为文件上传元素选择文件，与DomValSet不同的是本方法可以填充input[type=file]，选择后会触发元素的input和change事件
+传参：
+	selector：选择器，支持CSS、XPath、文本匹配，格式见Query
+	paths：本地文件路径，可传多个，元素未设置multiple时只能传一个，不传表示清空已选择的文件
+返回：
+	成功返回nil，未找到元素返回ErrNotFound，文件不存在时返回对应的错误，元素不是文件上传元素时返回*CDPError
+例：
+	tag.SetInputFiles("input[name=avatar]", "D:\\avatar.png")
+*/
+func (p *Tag) SetInputFiles(selector string, paths ...string) error {
+	el, err := p.Query(selector)
+	if err != nil {
+		return err
+	}
+	defer el.Release()
+	return el.SetInputFiles(paths...)
+}
+
+/**
+为文件上传元素选择文件
+传参：
+	paths：本地文件路径，可传多个，不传表示清空已选择的文件
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *ElementHandle) SetInputFiles(paths ...string) error {
+	files, err := absFiles(paths)
+	if err != nil {
+		return err
+	}
+	parm := make(map[string]interface{})
+	parm["files"] = files
+	parm["objectId"] = p.ObjectId
+	_, err = p.tag.Call("DOM.setFileInputFiles", parm)
+	return err
+}
+
+/**
+开启拦截文件选择对话框，开启后网页打开文件选择对话框时（如点击自定义的上传按钮）不再弹出对话框，改为触发回调
+传参：
+	handler：传入格式为func(tag *chrome.Tag, chooser *chrome.FileChooser)的函数，当网页打开文件选择对话框时会在新协程中自动触发
+返回：
+	成功返回nil，失败返回error错误信息
+例：
+	tag.HookFileChooserEn(func(tag *chrome.Tag, chooser *chrome.FileChooser) {
+		chooser.SetFiles("D:\\report.pdf")
+	})
+	tag.ClickElement("text=上传附件", chrome.ClickOptions{})
+*/
+func (p *Tag) HookFileChooserEn(handler func(tag *Tag, chooser *FileChooser)) error {
+	//回调在消息监听协程中读取，需加锁
+	p.taskLock.Lock()
+	p.fileChooserEvent = handler
+	p.taskLock.Unlock()
+	_, err := p.Call("Page.setInterceptFileChooserDialog", map[string]interface{}{"enabled": true})
+	return err
+}
+
+/**
+禁用拦截文件选择对话框，恢复弹出对话框
+返回：
+	成功返回nil，失败返回error错误信息
+*/
+func (p *Tag) HookFileChooserDis() error {
+	p.taskLock.Lock()
+	p.fileChooserEvent = nil
+	p.taskLock.Unlock()
+	_, err := p.Call("Page.setInterceptFileChooserDialog", map[string]interface{}{"enabled": false})
+	return err
+}
+
+/**
+为被拦截的对话框选择文件
+传参：
+	paths：本地文件路径，单选模式只能传一个
+返回：
+	成功返回nil，单选模式传多个文件时返回ErrInvalidParam，失败返回error错误信息
+*/
+func (p *FileChooser) SetFiles(paths ...string) error {
+	if p.Mode == "selectSingle" && len(paths) > 1 {
+		return ErrInvalidParam
+	}
+	files, err := absFiles(paths)
+	if err != nil {
+		return err
+	}
+	parm := make(map[string]interface{})
+	parm["files"] = files
+	parm["backendNodeId"] = p.BackendNodeId
+	_, err = p.tag.Call("DOM.setFileInputFiles", parm)
+	return err
+}
+
+/**
+将文件路径转为绝对路径并检查文件是否存在，浏览器只接受绝对路径
+*/
+func absFiles(paths []string) ([]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = os.Stat(abs); err != nil {
+			return nil, err
+		}
+		files = append(files, abs)
+	}
+	return files, nil
+}
@@ -0,0 +1,221 @@
+package chrome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//默认轮询间隔
+const defaultPollInterval = 100 * time.Millisecond
+
+//单次Runtime.evaluate最长等待时间，超过后重新发起，避免页面长时间不响应时调用一直挂起
+const waitSlice = 5 * time.Second
+
+//在页面中轮询执行fn，直到fn返回真值或超过slice毫秒，返回{ok:是否满足, value:fn的返回值}
+const waitJs = `(function(fn, poll, slice) {
+	return new Promise(function(resolve, reject) {
+		var end = Date.now() + slice;
+		(function check() {
+			var res;
+			try {
+				res = fn();
+			} catch (e) {
+				reject(e);
+				return;
+			}
+			if (res) {
+				resolve({ok: true, value: res});
+			} else if (Date.now() >= end) {
+				resolve({ok: false});
+			} else {
+				setTimeout(check, poll);
+			}
+		})();
+	});
+})(%s, %d, %d)`
+
+/**
+等待元素出现，比TagLoadWaitEnd更精确，元素出现后立即返回
+传参：
+	ctx：上下文，用于控制超时和取消
+	selector：选择器，支持CSS、XPath、文本匹配，格式见Query
+	opts：等待条件，默认等待元素存在，可指定等待可见或隐藏
+返回：
+	满足条件返回nil，超时或取消时error返回ctx.Err()，选择器错误时返回*JsError
+例：
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := tag.WaitForSelector(ctx, "#login", chrome.WaitOptions{Visible: true})
+*/
+func (p *Tag) WaitForSelector(ctx context.Context, selector string, opts WaitOptions) error {
+	if opts.Visible && opts.Hidden {
+		return ErrInvalidParam
+	}
+	cond := "!!el"
+	if opts.Visible {
+		cond = "visible"
+	} else if opts.Hidden {
+		cond = "!visible"
+	}
+	fn := fmt.Sprintf(`function() {
+		var el = %s;
+		var visible = !!el && getComputedStyle(el).visibility !== 'hidden' && !!(el.offsetWidth || el.offsetHeight || el.getClientRects().length);
+		return %s;
+	}`, querySelectorJs(selector), cond)
+	_, err := p.waitJs(ctx, fn, opts.Frame, defaultPollInterval)
+	return err
+}
+
+/**
+等待JS表达式返回真值
+传参：
+	ctx：上下文，用于控制超时和取消
+	jsPredicate：JS表达式，如：window.token && document.readyState === 'complete'
+	pollInterval：轮询间隔，传0表示默认100毫秒
+返回：
+	表达式的返回值，字符串类型直接返回文本，其他类型返回JSON文本，超时或取消时error返回ctx.Err()，表达式抛出异常时返回*JsError
+*/
+func (p *Tag) WaitForFunction(ctx context.Context, jsPredicate string, pollInterval time.Duration) (string, error) {
+	return p.waitJs(ctx, "function() {\n\t\treturn ("+jsPredicate+");\n\t}", 0, pollInterval)
+}
+
+/**
+等待主框架导航完成，即主框架跳转到新页面并触发load事件，或在页面内跳转（history.pushState、锚点）
+注意：需在触发跳转前开始等待，否则可能错过导航事件，一般另开协程触发跳转
+返回：
+	导航完成返回nil，超时或取消时error返回ctx.Err()，连接断开时返回ErrDisconnected
+例：
+	go tag.DomClick(0, "a.next")
+	err := tag.WaitForNavigation(ctx)
+*/
+func (p *Tag) WaitForNavigation(ctx context.Context) error {
+	done, err := p.sessionDone()
+	if err != nil {
+		return err
+	}
+	finish := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case finish <- struct{}{}:
+		default:
+		}
+	}
+	//事件回调都在消息监听协程中按顺序执行，navigated无需加锁
+	navigated := false
+	offNav := p.On("Page.frameNavigated", func(params json.RawMessage) {
+		var ev struct {
+			Frame struct {
+				ParentId string `json:"parentId"`
+			} `json:"frame"`
+		}
+		json.Unmarshal(params, &ev)
+		if ev.Frame.ParentId == "" {
+			navigated = true
+		}
+	})
+	defer offNav()
+	offLoad := p.On("Page.loadEventFired", func(params json.RawMessage) {
+		if navigated {
+			notify()
+		}
+	})
+	defer offLoad()
+	offInDoc := p.On("Page.navigatedWithinDocument", func(params json.RawMessage) {
+		var ev struct {
+			FrameId string `json:"frameId"`
+		}
+		json.Unmarshal(params, &ev)
+		//主框架的frameId与标签id相同
+		if ev.FrameId == p.Id {
+			notify()
+		}
+	})
+	defer offInDoc()
+	select {
+	case <-finish:
+		return nil
+	case <-done:
+		return ErrDisconnected
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/**
+在页面中轮询执行fn直到返回真值，页面跳转导致上下文销毁时会在新页面中继续等待
+传参：
+	ctx：上下文，用于控制超时和取消
+	fn：JS函数文本，返回真值表示满足条件
+	contextId：指定框架上下文id，传0表示默认主框架
+	poll：轮询间隔，传0表示默认100毫秒
+返回：
+	fn的返回值，字符串类型直接返回文本，其他类型返回JSON文本
+*/
+func (p *Tag) waitJs(ctx context.Context, fn string, contextId int, poll time.Duration) (string, error) {
+	if poll <= 0 {
+		poll = defaultPollInterval
+	}
+	for {
+		slice := waitSlice
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < slice {
+			slice = time.Until(deadline)
+		}
+		if slice <= 0 {
+			return "", context.DeadlineExceeded
+		}
+		parm := make(map[string]interface{})
+		parm["expression"] = fmt.Sprintf(waitJs, fn, poll.Milliseconds(), slice.Milliseconds())
+		parm["returnByValue"] = true
+		parm["awaitPromise"] = true
+		if contextId > 0 {
+			parm["contextId"] = contextId
+		}
+		res, err := p.CallContext(ctx, "Runtime.evaluate", parm)
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if err != nil {
+			var cdpErr *CDPError
+			if contextId == 0 && errors.As(err, &cdpErr) && strings.Contains(cdpErr.Message, "context") {
+				//页面跳转导致上下文销毁，等待新页面的上下文创建后重试
+				select {
+				case <-ctx.Done():
+					return "", ctx.Err()
+				case <-time.After(poll):
+				}
+				continue
+			}
+			return "", err
+		}
+		var rsp struct {
+			Result struct {
+				Result struct {
+					Value struct {
+						Ok    bool            `json:"ok"`
+						Value json.RawMessage `json:"value"`
+					} `json:"value"`
+				} `json:"result"`
+				ExceptionDetails json.RawMessage `json:"exceptionDetails"`
+			} `json:"result"`
+		}
+		if err = json.Unmarshal([]byte(res), &rsp); err != nil {
+			return "", err
+		}
+		if len(rsp.Result.ExceptionDetails) > 0 {
+			return "", newJsError(string(rsp.Result.ExceptionDetails))
+		}
+		if !rsp.Result.Result.Value.Ok {
+			//本轮未满足条件，继续下一轮等待
+			continue
+		}
+		var s string
+		if json.Unmarshal(rsp.Result.Result.Value.Value, &s) == nil {
+			return s, nil
+		}
+		return string(rsp.Result.Result.Value.Value), nil
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"b/chrome"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAttachTagWithAutoAttach(t *testing.T) {
+	tests := []struct {
+		name       string
+		eventFirst bool //附加事件是否先于响应到达
+	}{
+		{"event before response", true},
+		{"event after response", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, fb := newFakeChrome(t)
+			info := map[string]interface{}{"targetId": "T1", "type": "page", "url": "https://www.example.com/", "attached": true}
+			fb.handle("Target.getTargetInfo", func(map[string]interface{}) interface{} {
+				return map[string]interface{}{"targetInfo": info}
+			})
+			fb.handle("Target.attachToTarget", func(map[string]interface{}) interface{} {
+				event := map[string]interface{}{"sessionId": "S1", "targetInfo": info, "waitingForDebugger": false}
+				if tt.eventFirst {
+					fb.emit("Target.attachedToTarget", event)
+				} else {
+					go func() {
+						time.Sleep(20 * time.Millisecond)
+						fb.emit("Target.attachedToTarget", event)
+					}()
+				}
+				return map[string]interface{}{"sessionId": "S1"}
+			})
+			var lock sync.Mutex
+			auto := make([]*chrome.Tag, 0)
+			if err := b.AutoAttach(func(tag *chrome.Tag) {
+				lock.Lock()
+				auto = append(auto, tag)
+				lock.Unlock()
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			tag, err := b.AttachTag("T1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(chan struct{}, 1)
+			tag.On("Test.ping", func(params json.RawMessage) {
+				got <- struct{}{}
+			})
+			time.Sleep(50 * time.Millisecond)
+			fb.emitSession("S1", "Test.ping", map[string]interface{}{})
+			select {
+			case <-got:
+			case <-time.After(time.Second):
+				t.Fatal("手动附加的标签收不到会话事件")
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			if len(auto) != 0 {
+				t.Errorf("手动附加的会话不应再创建标签，实际自动附加了%d个", len(auto))
+			}
+		})
+	}
+}
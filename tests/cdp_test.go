@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"b/chrome"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+//模拟浏览器收到的一次方法调用
+type fakeCall struct {
+	Method string
+	Params map[string]interface{}
+}
+
+//模拟浏览器返回的错误响应
+type fakeError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+//模拟浏览器调试接口，记录收到的全部调用，未通过handle指定响应的方法都返回{"success":true}
+type fakeBrowser struct {
+	srv      *httptest.Server
+	lock     sync.Mutex
+	calls    []fakeCall
+	handlers map[string]func(params map[string]interface{}) interface{} //按方法名指定响应，返回*fakeError时响应错误
+	ws       *websocket.Conn                                            //当前连接，用于推送事件
+	wlock    sync.Mutex                                                 //连接写入互斥锁
+}
+
+/**
+启动模拟浏览器，/json/version返回自身的ws地址，其他路径均作为ws连接处理，测试结束时自动关闭
+*/
+func newFakeBrowser(t *testing.T) *fakeBrowser {
+	fb := &fakeBrowser{}
+	upgrader := websocket.Upgrader{}
+	fb.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json/version" {
+			w.Write([]byte(`{"webSocketDebuggerUrl":"` + fb.wsUrl() + `/devtools/browser/fake"}`))
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		fb.lock.Lock()
+		fb.ws = ws
+		fb.lock.Unlock()
+		for {
+			var msg struct {
+				Id        int                    `json:"id"`
+				Method    string                 `json:"method"`
+				Params    map[string]interface{} `json:"params"`
+				SessionId string                 `json:"sessionId"`
+			}
+			if ws.ReadJSON(&msg) != nil {
+				return
+			}
+			fb.lock.Lock()
+			fb.calls = append(fb.calls, fakeCall{Method: msg.Method, Params: msg.Params})
+			handler := fb.handlers[msg.Method]
+			fb.lock.Unlock()
+			rsp := map[string]interface{}{"id": msg.Id}
+			if msg.SessionId != "" {
+				rsp["sessionId"] = msg.SessionId
+			}
+			var result interface{} = map[string]interface{}{"success": true}
+			if handler != nil {
+				result = handler(msg.Params)
+			}
+			if e, ok := result.(*fakeError); ok {
+				rsp["error"] = e
+			} else {
+				rsp["result"] = result
+			}
+			if fb.write(rsp) != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(fb.srv.Close)
+	return fb
+}
+
+/**
+启动模拟浏览器并返回连接到它的标签
+*/
+func newFakeTag(t *testing.T) (*chrome.Tag, *fakeBrowser) {
+	fb := newFakeBrowser(t)
+	tag := &chrome.Tag{WebSocketDebuggerUrl: fb.wsUrl() + "/devtools/page/fake"}
+	if _, err := tag.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tag.Close(false) })
+	fb.reset()
+	return tag, fb
+}
+
+/**
+启动模拟浏览器并返回连接到它的浏览器对象
+*/
+func newFakeChrome(t *testing.T) (*chrome.Browser, *fakeBrowser) {
+	fb := newFakeBrowser(t)
+	u, _ := url.Parse(fb.srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	b := &chrome.Browser{Ip: u.Hostname(), Port: port}
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(b.Disconnect)
+	return b, fb
+}
+
+/**
+模拟浏览器的ws地址前缀
+*/
+func (fb *fakeBrowser) wsUrl() string {
+	return "ws" + strings.TrimPrefix(fb.srv.URL, "http")
+}
+
+/**
+指定方法的响应，handler的返回值作为result，返回*fakeError时响应错误
+*/
+func (fb *fakeBrowser) handle(method string, handler func(params map[string]interface{}) interface{}) {
+	fb.lock.Lock()
+	defer fb.lock.Unlock()
+	if fb.handlers == nil {
+		fb.handlers = make(map[string]func(params map[string]interface{}) interface{})
+	}
+	fb.handlers[method] = handler
+}
+
+/**
+取指定方法的调用记录
+*/
+func (fb *fakeBrowser) called(method string) []fakeCall {
+	fb.lock.Lock()
+	defer fb.lock.Unlock()
+	res := make([]fakeCall, 0)
+	for _, v := range fb.calls {
+		if v.Method == method {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+/**
+清空调用记录
+*/
+func (fb *fakeBrowser) reset() {
+	fb.lock.Lock()
+	fb.calls = nil
+	fb.lock.Unlock()
+}
+
+/**
+向标签推送事件
+*/
+func (fb *fakeBrowser) emit(method string, params interface{}) error {
+	return fb.write(map[string]interface{}{"method": method, "params": params})
+}
+
+/**
+向通过浏览器连接附加的会话推送事件
+*/
+func (fb *fakeBrowser) emitSession(sessionId string, method string, params interface{}) error {
+	return fb.write(map[string]interface{}{"method": method, "params": params, "sessionId": sessionId})
+}
+
+func (fb *fakeBrowser) write(msg interface{}) error {
+	fb.lock.Lock()
+	ws := fb.ws
+	fb.lock.Unlock()
+	fb.wlock.Lock()
+	defer fb.wlock.Unlock()
+	return ws.WriteJSON(msg)
+}
+
+/**
+等待指定方法的调用次数达到n，超时返回false
+*/
+func (fb *fakeBrowser) wait(method string, n int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if len(fb.called(method)) >= n {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
@@ -158,7 +158,11 @@ func hkreq(tag *chrome.Tag, req chrome.HookHttpRequest) {
 
 func hkResp(tag *chrome.Tag, resp chrome.HookHttpResponse) {
 	if resp.Params.Response.Url == "https://www.southwest.com/api/air-booking/v1/air-booking/page/air/booking/shopping" {
-		res := tag.HookGetBody(resp.Params.RequestId)
+		res, err := tag.HookGetBody(resp.Params.RequestId)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
 		fmt.Println(res.Result.Body)
 	}
 }
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"b/chrome"
+	"net/http/cookiejar"
+	"testing"
+)
+
+func TestCookieFormats(t *testing.T) {
+	cookies := []chrome.Cookie{
+		{Name: "sid", Value: "abc", Domain: ".example.com", Path: "/", Expires: 1893456000, HTTPOnly: true, Secure: true, SameSite: "Lax"},
+		{Name: "tmp", Value: "1", Domain: "www.example.com", Path: "/app", Expires: -1, Session: true},
+	}
+
+	//Netscape格式往返
+	list, err := chrome.CookiesFromNetscape(chrome.CookiesToNetscape(cookies))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Domain != ".example.com" || !list[0].HTTPOnly || !list[0].Secure || list[0].Expires != 1893456000 {
+		t.Fatalf("Netscape往返结果错误：%+v", list)
+	}
+	if !list[1].Session || list[1].Path != "/app" || list[1].HTTPOnly {
+		t.Fatalf("Netscape会话Cookie错误：%+v", list[1])
+	}
+
+	//插件JSON格式往返
+	data, err := chrome.CookiesToJson(cookies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err = chrome.CookiesFromJson(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].SameSite != "Lax" || list[0].Expires != 1893456000 || !list[1].Session {
+		t.Fatalf("JSON往返结果错误：%+v", list)
+	}
+
+	//http.CookieJar互通
+	jar, _ := cookiejar.New(nil)
+	chrome.CookiesToJar(jar, cookies)
+	list, err = chrome.CookiesFromJar(jar, "https://www.example.com/app/index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("CookieJar结果错误：%+v", list)
+	}
+}
+
+func TestCookiesSetHostOnly(t *testing.T) {
+	tag, fb := newFakeTag(t)
+
+	tests := []struct {
+		name    string
+		cookie  chrome.Cookie
+		domain  string  //期望发送的domain，空表示不发送
+		url     string  //期望发送的url
+		expires float64 //期望发送的expires，0表示不发送
+	}{
+		{"host-only", chrome.Cookie{Name: "a", Value: "1", Domain: "www.example.com", Path: "/", Expires: 1893456000}, "", "http://www.example.com/", 1893456000},
+		{"host-only secure", chrome.Cookie{Name: "b", Value: "2", Domain: "www.example.com", Path: "/app", Secure: true}, "", "https://www.example.com/app", 0},
+		{"host-only with url", chrome.Cookie{Name: "c", Value: "3", Domain: "www.example.com", Url: "https://www.example.com/"}, "", "https://www.example.com/", 0},
+		{"domain", chrome.Cookie{Name: "d", Value: "4", Domain: ".example.com", Path: "/", Expires: 1893456000}, ".example.com", "http://example.com/", 1893456000},
+		{"session", chrome.Cookie{Name: "s", Value: "5", Domain: ".example.com", Path: "/", Expires: -1, Session: true}, ".example.com", "http://example.com/", 0},
+		{"session with expires", chrome.Cookie{Name: "t", Value: "6", Domain: "www.example.com", Path: "/", Expires: 1893456000, Session: true}, "", "http://www.example.com/", 0},
+	}
+	for _, tt := range tests {
+		if err := tag.CookiesSet(tt.cookie); err != nil {
+			t.Fatalf("%s：%v", tt.name, err)
+		}
+	}
+	got := fb.called("Network.setCookie")
+	if len(got) != len(tests) {
+		t.Fatalf("期望%d次setCookie，实际%d次", len(tests), len(got))
+	}
+	for i, tt := range tests {
+		parm := got[i].Params
+		domain, hasDomain := parm["domain"]
+		if tt.domain == "" && hasDomain {
+			t.Errorf("%s：主机Cookie不应发送domain，实际%v", tt.name, domain)
+		}
+		if tt.domain != "" && domain != tt.domain {
+			t.Errorf("%s：domain期望%s，实际%v", tt.name, tt.domain, domain)
+		}
+		if parm["url"] != tt.url {
+			t.Errorf("%s：url期望%s，实际%v", tt.name, tt.url, parm["url"])
+		}
+		//会话Cookie不能带过期时间，否则浏览器按1970年处理直接丢弃
+		expires, hasExpires := parm["expires"]
+		if tt.expires == 0 && hasExpires {
+			t.Errorf("%s：会话Cookie不应发送expires，实际%v", tt.name, expires)
+		}
+		if tt.expires != 0 && expires != tt.expires {
+			t.Errorf("%s：expires期望%v，实际%v", tt.name, tt.expires, expires)
+		}
+		//未设置的优先级和SameSite不发送空文本
+		for _, k := range []string{"priority", "sameSite"} {
+			if _, ok := parm[k]; ok {
+				t.Errorf("%s：不应发送空的%s", tt.name, k)
+			}
+		}
+	}
+
+	//已设置的SameSite和优先级照常发送
+	if err := tag.CookiesSet(chrome.Cookie{Name: "e", Value: "5", Domain: ".example.com", SameSite: "Lax", Priority: "High"}); err != nil {
+		t.Fatal(err)
+	}
+	got = fb.called("Network.setCookie")
+	if last := got[len(got)-1].Params; last["sameSite"] != "Lax" || last["priority"] != "High" {
+		t.Errorf("SameSite、优先级未发送：%v", last)
+	}
+}
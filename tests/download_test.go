@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"b/chrome"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestDownloadRename(t *testing.T) {
+	b, fb := newFakeChrome(t)
+	dir := t.TempDir()
+	if err := b.SetDownloadBehavior(dir); err != nil {
+		t.Fatal(err)
+	}
+	//已存在的同名文件
+	if err := os.WriteFile(filepath.Join(dir, "old.txt"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan chrome.Download, 1)
+	unsubscribe := b.OnDownload(func(d chrome.Download) {
+		if d.State != "inProgress" {
+			ch <- d
+		}
+	})
+	defer unsubscribe()
+
+	tests := []struct {
+		name  string //建议的文件名
+		state string //下载结果
+		want  string //期望的文件名，空表示保留任务ID
+	}{
+		{"report.pdf", "completed", "report.pdf"},
+		{"report.pdf", "completed", "report (1).pdf"},
+		{"report.pdf", "completed", "report (2).pdf"},
+		{"old.txt", "completed", "old (1).txt"},
+		{"README", "completed", "README"},
+		{"README", "completed", "README (1)"},
+		{"archive.tar.gz", "completed", "archive.tar.gz"},
+		{"../x", "completed", "x"},
+		{"../../etc/passwd", "completed", "passwd"},
+		{`..\..\evil.exe`, "completed", "evil.exe"},
+		{`C:\Windows\win.ini`, "completed", "win.ini"},
+		{"/abs/path.bin", "completed", "path.bin"},
+		{"..", "completed", ""},
+		{".", "completed", ""},
+		{"", "completed", ""},
+		{"/", "completed", ""},
+		{"cancel.zip", "canceled", ""},
+	}
+	for i, tt := range tests {
+		guid := "guid-" + strconv.Itoa(i)
+		content := []byte("file " + strconv.Itoa(i))
+		if err := os.WriteFile(filepath.Join(dir, guid), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fb.emit("Browser.downloadWillBegin", map[string]interface{}{"guid": guid, "frameId": "F", "url": "http://example.com/f", "suggestedFilename": tt.name})
+		fb.emit("Browser.downloadProgress", map[string]interface{}{"guid": guid, "state": tt.state, "totalBytes": len(content), "receivedBytes": len(content)})
+		var d chrome.Download
+		select {
+		case d = <-ch:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("%q：等待下载事件超时", tt.name)
+		}
+		want := tt.want
+		if want == "" {
+			want = guid
+		}
+		if d.Path != filepath.Join(dir, want) {
+			t.Errorf("%q：保存路径期望%s，实际%s", tt.name, filepath.Join(dir, want), d.Path)
+			continue
+		}
+		data, err := os.ReadFile(d.Path)
+		if err != nil || string(data) != string(content) {
+			t.Errorf("%q：文件内容错误：%q %v", tt.name, data, err)
+		}
+	}
+
+	//重命名只发生在下载目录内，没有文件写到目录外
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "x")); !os.IsNotExist(err) {
+		t.Errorf("文件被写到下载目录之外：%v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "old.txt")); string(data) != "old" {
+		t.Errorf("已存在的文件被覆盖：%q", data)
+	}
+}
+
+func TestDownloadBehaviorContexts(t *testing.T) {
+	b, fb := newFakeChrome(t)
+	n := 0
+	fb.handle("Target.createBrowserContext", func(map[string]interface{}) interface{} {
+		n++
+		return map[string]interface{}{"browserContextId": "C" + strconv.Itoa(n)}
+	})
+	if _, err := b.NewContext(chrome.ContextOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := b.SetDownloadBehavior(dir); err != nil {
+		t.Fatal(err)
+	}
+	//设置下载目录后新建的上下文也要生效
+	if _, err := b.NewContext(chrome.ContextOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	contexts := make(map[string]bool)
+	for _, v := range fb.called("Browser.setDownloadBehavior") {
+		if v.Params["behavior"] != "allowAndName" || v.Params["downloadPath"] != dir || v.Params["eventsEnabled"] != true {
+			t.Errorf("下载设置参数错误：%v", v.Params)
+		}
+		id, _ := v.Params["browserContextId"].(string)
+		contexts[id] = true
+	}
+	if !reflect.DeepEqual(contexts, map[string]bool{"": true, "C1": true, "C2": true}) {
+		t.Errorf("期望默认上下文和C1、C2都设置下载行为，实际%v", contexts)
+	}
+}
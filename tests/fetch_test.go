@@ -0,0 +1,50 @@
+package tests
+
+import (
+	"b/chrome"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//拦截请求的同时开关拦截，回调应只在开启期间触发，未处理的请求自动放行；需配合-race运行检查数据竞争
+func TestHookFetchToggle(t *testing.T) {
+	tag, fb := newFakeTag(t)
+	hit := make(chan string, 100)
+	handler := func(tag *chrome.Tag, req *chrome.FetchRequest) {
+		hit <- req.RequestId
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := tag.HookFetchEn(nil, handler); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := tag.HookFetchDis(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		fb.emit("Fetch.requestPaused", map[string]interface{}{"requestId": "r" + strconv.Itoa(i)})
+	}
+	<-done
+	if err := tag.HookFetchEn(nil, handler); err != nil {
+		t.Fatal(err)
+	}
+	fb.emit("Fetch.requestPaused", map[string]interface{}{"requestId": "last"})
+	timeout := time.After(5 * time.Second)
+	for id := ""; id != "last"; {
+		select {
+		case id = <-hit:
+		case <-timeout:
+			t.Fatal("开启拦截后回调未触发")
+		}
+	}
+	if !fb.wait("Fetch.continueRequest", 1, 5*time.Second) {
+		t.Fatal("回调未处理的请求没有自动放行")
+	}
+}
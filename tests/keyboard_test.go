@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"b/chrome"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPressAccelerator(t *testing.T) {
+	tag, fb := newFakeTag(t)
+	tests := []struct {
+		keys   string
+		events []string //期望依次发送的事件：类型:按键值:功能键
+	}{
+		{"Enter", []string{"keyDown:Enter:0", "keyUp:Enter:0"}},
+		{"Control+A", []string{"rawKeyDown:Control:2", "rawKeyDown:A:2", "keyUp:A:2", "keyUp:Control:0"}},
+		{"Ctrl+c", []string{"rawKeyDown:Control:2", "rawKeyDown:c:2", "keyUp:c:2", "keyUp:Control:0"}},
+		{"Shift+Tab", []string{"rawKeyDown:Shift:8", "rawKeyDown:Tab:8", "keyUp:Tab:8", "keyUp:Shift:0"}},
+		{"Control++", []string{"rawKeyDown:Control:2", "rawKeyDown:+:2", "keyUp:+:2", "keyUp:Control:0"}},
+		{"+", []string{"keyDown:+:0", "keyUp:+:0"}},
+		{"Control+Shift+ArrowLeft", []string{"rawKeyDown:Control:2", "rawKeyDown:Shift:10", "rawKeyDown:ArrowLeft:10", "keyUp:ArrowLeft:10", "keyUp:Shift:2", "keyUp:Control:0"}},
+	}
+	for _, tt := range tests {
+		fb.reset()
+		if err := tag.Press(tt.keys); err != nil {
+			t.Fatalf("%s：%v", tt.keys, err)
+		}
+		events := make([]string, 0)
+		for _, v := range fb.called("Input.dispatchKeyEvent") {
+			events = append(events, v.Params["type"].(string)+":"+v.Params["key"].(string)+":"+formatModifiers(v.Params["modifiers"]))
+		}
+		if !reflect.DeepEqual(events, tt.events) {
+			t.Errorf("%s：期望%v，实际%v", tt.keys, tt.events, events)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"Control+",
+		"Control+Shift+",
+		"Foo",
+		"Control+Foo",
+		"Control+Control",
+		"Ctrl+Control+A",
+		"a+A",
+		"Shift++Enter",
+	}
+	for _, keys := range invalid {
+		fb.reset()
+		if err := tag.Press(keys); !errors.Is(err, chrome.ErrInvalidParam) {
+			t.Errorf("%q：期望ErrInvalidParam，实际%v", keys, err)
+		}
+		if n := len(fb.called("Input.dispatchKeyEvent")); n != 0 {
+			t.Errorf("%q：无效组合键不应按下任何按键，实际发送%d个事件", keys, n)
+		}
+	}
+}
+
+func formatModifiers(v interface{}) string {
+	f, _ := v.(float64)
+	return strconv.Itoa(int(f))
+}
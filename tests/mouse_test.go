@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"b/chrome"
+	"math"
+	"testing"
+)
+
+func TestTrajectoryEndpoints(t *testing.T) {
+	tracks := []struct {
+		name  string
+		track chrome.Trajectory
+	}{
+		{"linear", &chrome.LinearTrack{}},
+		{"linear ease", &chrome.LinearTrack{Steps: 7, Velocity: chrome.VelocityEaseInOut}},
+		{"bezier", &chrome.BezierTrack{}},
+		{"bezier wide", &chrome.BezierTrack{Spread: 0.8, Jitter: 3}},
+		{"overshoot", &chrome.OvershootTrack{}},
+		{"overshoot linear", &chrome.OvershootTrack{Track: &chrome.LinearTrack{}, Distance: 30, Pause: 50}},
+	}
+	moves := [][4]int{
+		{0, 0, 800, 600},
+		{800, 600, 0, 0},
+		{100, 100, 100, 100},
+		{10, 10, 30, 15},
+		{-20, 50, 1900, -40},
+	}
+	for _, tt := range tracks {
+		//轨迹带随机成分，多跑几次
+		for n := 0; n < 20; n++ {
+			for _, m := range moves {
+				points := tt.track.Points(m[0], m[1], m[2], m[3])
+				if len(points) == 0 {
+					t.Fatalf("%s %v：没有轨迹点", tt.name, m)
+				}
+				if last := points[len(points)-1]; last.X != m[2] || last.Y != m[3] {
+					t.Fatalf("%s %v：终点为(%d,%d)", tt.name, m, last.X, last.Y)
+				}
+			}
+		}
+	}
+
+	//指定点数时按点数生成
+	if n := len((&chrome.LinearTrack{Steps: 12}).Points(0, 0, 500, 0)); n != 12 {
+		t.Errorf("LinearTrack点数期望12，实际%d", n)
+	}
+	if n := len((&chrome.BezierTrack{Steps: 9}).Points(0, 0, 500, 0)); n != 9 {
+		t.Errorf("BezierTrack点数期望9，实际%d", n)
+	}
+
+	//不弯曲不抖动时贝塞尔轨迹在直线上
+	for _, pt := range (&chrome.BezierTrack{Spread: -1, Jitter: -1}).Points(0, 0, 300, 300) {
+		if math.Abs(float64(pt.X-pt.Y)) > 1 {
+			t.Fatalf("不弯曲的贝塞尔轨迹偏离直线：(%d,%d)", pt.X, pt.Y)
+		}
+	}
+
+	//距离足够长时冲过终点
+	over := false
+	for n := 0; n < 20 && !over; n++ {
+		for _, pt := range (&chrome.OvershootTrack{Track: &chrome.LinearTrack{}}).Points(0, 0, 1000, 0) {
+			if pt.X > 1000 {
+				over = true
+			}
+		}
+	}
+	if !over {
+		t.Error("OvershootTrack没有冲过终点")
+	}
+}
+
+func TestTrajectoryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max int
+		wantMin  int
+		wantMax  int
+	}{
+		{"default", 0, 0, 5, 15},
+		{"custom", 20, 40, 20, 40},
+		{"equal", 10, 10, 10, 10},
+		{"min above max", 50, 30, 30, 30},
+		{"negative min", -10, 20, 0, 20},
+		{"no delay", 10, -1, 0, 0},
+		{"both negative", -5, -5, 0, 0},
+	}
+	for _, tt := range tests {
+		tracks := []chrome.Trajectory{
+			&chrome.LinearTrack{MinDelay: tt.min, MaxDelay: tt.max},
+			&chrome.BezierTrack{MinDelay: tt.min, MaxDelay: tt.max},
+		}
+		for _, track := range tracks {
+			for _, pt := range track.Points(0, 0, 200, 100) {
+				if pt.MinDelay != tt.wantMin || pt.MaxDelay != tt.wantMax {
+					t.Fatalf("%s %T：延迟期望%d~%d，实际%d~%d", tt.name, track, tt.wantMin, tt.wantMax, pt.MinDelay, pt.MaxDelay)
+				}
+			}
+		}
+	}
+
+	//冲过后的停顿加在回程首个点上，延迟范围保持有序且非负
+	for n := 0; n < 20; n++ {
+		for _, pt := range (&chrome.OvershootTrack{Pause: 90}).Points(0, 0, 800, 0) {
+			if pt.MinDelay < 0 || pt.MinDelay > pt.MaxDelay {
+				t.Fatalf("OvershootTrack延迟范围错误：%d~%d", pt.MinDelay, pt.MaxDelay)
+			}
+		}
+	}
+}
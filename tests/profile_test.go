@@ -0,0 +1,262 @@
+package tests
+
+import (
+	"b/chrome"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionProfileJson(t *testing.T) {
+	profile := chrome.SessionProfile{
+		Version:   chrome.SessionProfileVersion,
+		Created:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		UserAgent: "Mozilla/5.0 Test",
+		Cookies: []chrome.Cookie{
+			{Name: "sid", Value: "abc", Domain: ".example.com", Path: "/", Expires: 1893456000, HTTPOnly: true, Secure: true, SameSite: "Lax"},
+		},
+		Origins: []chrome.OriginStorage{
+			{
+				Origin:         "https://www.example.com",
+				LocalStorage:   map[string]string{"token": "x\"y</script>"},
+				SessionStorage: map[string]string{"step": "2"},
+				IndexedDB:      json.RawMessage(`[{"name":"db","version":1,"stores":[]}]`),
+			},
+			{Origin: "https://static.example.com"},
+		},
+	}
+	data, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got chrome.SessionProfile
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != profile.Version || !got.Created.Equal(profile.Created) || got.UserAgent != profile.UserAgent {
+		t.Fatalf("档案头往返结果错误：%+v", got)
+	}
+	if !reflect.DeepEqual(got.Cookies, profile.Cookies) {
+		t.Fatalf("Cookie往返结果错误：%+v", got.Cookies)
+	}
+	if len(got.Origins) != 2 || !reflect.DeepEqual(got.Origins[0].LocalStorage, profile.Origins[0].LocalStorage) ||
+		!reflect.DeepEqual(got.Origins[0].SessionStorage, profile.Origins[0].SessionStorage) ||
+		string(got.Origins[0].IndexedDB) != string(profile.Origins[0].IndexedDB) {
+		t.Fatalf("源存储往返结果错误：%+v", got.Origins)
+	}
+	if got.Origins[1].LocalStorage != nil || got.Origins[1].IndexedDB != nil {
+		t.Fatalf("空存储应省略：%+v", got.Origins[1])
+	}
+}
+
+func TestSessionProfileVersion(t *testing.T) {
+	//版本检查在调用浏览器之前，未连接的标签也能验证
+	tag := &chrome.Tag{}
+	tests := []struct {
+		name    string
+		profile *chrome.SessionProfile
+	}{
+		{"nil", nil},
+		{"newer", &chrome.SessionProfile{Version: chrome.SessionProfileVersion + 1}},
+		{"far newer", &chrome.SessionProfile{Version: chrome.SessionProfileVersion + 100}},
+	}
+	for _, tt := range tests {
+		if err := tag.ImportSession(tt.profile); !errors.Is(err, chrome.ErrInvalidParam) {
+			t.Errorf("%s：期望ErrInvalidParam，实际%v", tt.name, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "profile.json")
+	data, _ := json.Marshal(chrome.SessionProfile{Version: chrome.SessionProfileVersion + 1})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := tag.ImportSessionFromFile(path); !errors.Is(err, chrome.ErrInvalidParam) {
+		t.Errorf("从文件导入高版本档案：期望ErrInvalidParam，实际%v", err)
+	}
+}
+
+//模拟页面框架树：主框架和一个子框架，about:blank框架没有源
+func frameTree(origins ...string) func(map[string]interface{}) interface{} {
+	return func(map[string]interface{}) interface{} {
+		children := make([]interface{}, 0)
+		for _, v := range origins[1:] {
+			children = append(children, map[string]interface{}{"frame": map[string]interface{}{"id": v, "securityOrigin": v}})
+		}
+		children = append(children, map[string]interface{}{"frame": map[string]interface{}{"id": "blank", "securityOrigin": "://"}})
+		return map[string]interface{}{"frameTree": map[string]interface{}{
+			"frame":       map[string]interface{}{"id": "main", "securityOrigin": origins[0]},
+			"childFrames": children,
+		}}
+	}
+}
+
+func TestExportSession(t *testing.T) {
+	tag, fb := newFakeTag(t)
+	fb.handle("Network.getAllCookies", func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"cookies": []interface{}{
+			map[string]interface{}{"name": "sid", "value": "abc", "domain": ".example.com", "path": "/", "expires": 1893456000, "session": false},
+			map[string]interface{}{"name": "tmp", "value": "1", "domain": "www.example.com", "path": "/", "expires": -1, "session": true},
+		}}
+	})
+	fb.handle("Browser.getVersion", func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"userAgent": "Mozilla/5.0 Fake"}
+	})
+	fb.handle("Page.getFrameTree", frameTree("https://www.example.com", "https://static.example.com"))
+	storage := map[string][][]string{
+		"https://www.example.com|true":     {{"token", "t1"}, {"theme", "dark"}},
+		"https://www.example.com|false":    {{"step", "2"}},
+		"https://static.example.com|true":  {{"cdn", "1"}},
+		"https://static.example.com|false": {},
+	}
+	fb.handle("DOMStorage.getDOMStorageItems", func(params map[string]interface{}) interface{} {
+		id := params["storageId"].(map[string]interface{})
+		entries, ok := storage[id["securityOrigin"].(string)+"|"+strconv.FormatBool(id["isLocalStorage"].(bool))]
+		if !ok {
+			//未打开的源浏览器找不到对应框架
+			return &fakeError{Code: -32000, Message: "Frame not found for the given storage id"}
+		}
+		return map[string]interface{}{"entries": entries}
+	})
+	fb.handle("Runtime.evaluate", func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": []interface{}{map[string]interface{}{"name": "db", "version": 2, "stores": []interface{}{}}}}}
+	})
+
+	profile, err := tag.ExportSession(chrome.SessionOptions{Origins: []string{"https://other.example.com/"}, IndexedDB: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Version != chrome.SessionProfileVersion || profile.UserAgent != "Mozilla/5.0 Fake" {
+		t.Errorf("档案头错误：%+v", profile)
+	}
+	if len(profile.Cookies) != 2 || profile.Cookies[0].Name != "sid" || !profile.Cookies[1].Session {
+		t.Errorf("Cookie导出错误：%+v", profile.Cookies)
+	}
+	//未打开的额外源被跳过，about:blank没有源
+	if len(profile.Origins) != 2 {
+		t.Fatalf("期望导出2个源，实际%+v", profile.Origins)
+	}
+	main, sub := profile.Origins[0], profile.Origins[1]
+	if main.Origin != "https://www.example.com" || !reflect.DeepEqual(main.LocalStorage, map[string]string{"token": "t1", "theme": "dark"}) ||
+		!reflect.DeepEqual(main.SessionStorage, map[string]string{"step": "2"}) {
+		t.Errorf("主框架源导出错误：%+v", main)
+	}
+	if string(main.IndexedDB) != `[{"name":"db","stores":[],"version":2}]` {
+		t.Errorf("IndexedDB导出错误：%s", main.IndexedDB)
+	}
+	if sub.Origin != "https://static.example.com" || !reflect.DeepEqual(sub.LocalStorage, map[string]string{"cdn": "1"}) || sub.SessionStorage != nil || sub.IndexedDB != nil {
+		t.Errorf("子框架源导出错误：%+v", sub)
+	}
+}
+
+func TestImportSession(t *testing.T) {
+	tag, fb := newFakeTag(t)
+	fb.handle("Page.getFrameTree", frameTree("https://www.example.com"))
+	fb.handle("Runtime.evaluate", func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"result": map[string]interface{}{"type": "object", "objectId": "window-1"}}
+	})
+	fb.handle("Runtime.callFunctionOn", func(map[string]interface{}) interface{} {
+		return map[string]interface{}{"result": map[string]interface{}{"type": "boolean", "value": true}}
+	})
+	//值中带引号和脚本结束标签，验证数据不会拼接进脚本
+	evil := `"});alert(1);//</script>`
+	idb := `[{"name":"db","version":1,"stores":[{"name":"s","keyPath":null,"records":[{"key":"k","value":"` + strings.ReplaceAll(evil, `"`, `\"`) + `"}]}]}]`
+	profile := &chrome.SessionProfile{
+		Version: chrome.SessionProfileVersion,
+		Cookies: []chrome.Cookie{
+			{Name: "sid", Value: "abc", Domain: ".example.com", Path: "/", Expires: 1893456000, Secure: true},
+			{Name: "tmp", Value: "1", Domain: "www.example.com", Path: "/", Expires: -1, Session: true},
+		},
+		Origins: []chrome.OriginStorage{
+			{Origin: "https://www.example.com", LocalStorage: map[string]string{"token": "t1"}, SessionStorage: map[string]string{"step": "2"}, IndexedDB: json.RawMessage(idb)},
+			{Origin: "https://other.example.com", LocalStorage: map[string]string{"x": evil}},
+		},
+	}
+	if err := tag.ImportSession(profile); err != nil {
+		t.Fatal(err)
+	}
+
+	cookies := fb.called("Network.setCookie")
+	if len(cookies) != 2 {
+		t.Fatalf("期望设置2个Cookie，实际%d", len(cookies))
+	}
+	if c := cookies[0].Params; c["domain"] != ".example.com" || c["url"] != "https://example.com/" || c["expires"] != float64(1893456000) {
+		t.Errorf("域名Cookie参数错误：%v", c)
+	}
+	if c := cookies[1].Params; c["url"] != "http://www.example.com/" || c["domain"] != nil || c["expires"] != nil {
+		t.Errorf("会话Cookie参数错误，不应带domain和expires：%v", c)
+	}
+
+	//已打开的源直接写入存储
+	items := make(map[string]string)
+	for _, v := range fb.called("DOMStorage.setDOMStorageItem") {
+		id := v.Params["storageId"].(map[string]interface{})
+		if id["securityOrigin"] != "https://www.example.com" {
+			t.Errorf("未打开的源不应通过DOMStorage写入：%v", v.Params)
+		}
+		items[strconv.FormatBool(id["isLocalStorage"].(bool))+"|"+v.Params["key"].(string)] = v.Params["value"].(string)
+	}
+	if !reflect.DeepEqual(items, map[string]string{"true|token": "t1", "false|step": "2"}) {
+		t.Errorf("DOMStorage写入错误：%v", items)
+	}
+
+	//未打开的源注册加载时恢复的脚本，数据按JSON嵌入
+	scripts := fb.called("Page.addScriptToEvaluateOnNewDocument")
+	if len(scripts) != 1 {
+		t.Fatalf("期望注册1个恢复脚本，实际%d", len(scripts))
+	}
+	source := scripts[0].Params["source"].(string)
+	data, _ := json.Marshal(evil)
+	if !strings.Contains(source, `"origin":"https://other.example.com"`) || !strings.Contains(source, string(data)) || strings.Contains(source, evil) {
+		t.Errorf("恢复脚本数据嵌入错误：%s", source)
+	}
+
+	//IndexedDB数据作为调用参数传入
+	calls := fb.called("Runtime.callFunctionOn")
+	if len(calls) != 1 {
+		t.Fatalf("期望调用1次callFunctionOn，实际%d", len(calls))
+	}
+	if calls[0].Params["objectId"] != "window-1" || strings.Contains(calls[0].Params["functionDeclaration"].(string), evil) {
+		t.Errorf("IndexedDB导入调用错误：%v", calls[0].Params)
+	}
+	args := calls[0].Params["arguments"].([]interface{})
+	var want interface{}
+	json.Unmarshal([]byte(idb), &want)
+	if len(args) != 1 || !reflect.DeepEqual(args[0].(map[string]interface{})["value"], want) {
+		t.Errorf("IndexedDB参数错误：%v", args)
+	}
+}
+
+func TestImportSessionPartial(t *testing.T) {
+	tag, fb := newFakeTag(t)
+	fb.handle("Page.getFrameTree", frameTree("https://www.example.com"))
+	fb.handle("DOMStorage.setDOMStorageItem", func(map[string]interface{}) interface{} {
+		return &fakeError{Code: -32000, Message: "Frame not found for the given storage id"}
+	})
+	profile := &chrome.SessionProfile{
+		Version: chrome.SessionProfileVersion,
+		Cookies: []chrome.Cookie{{Name: "sid", Value: "abc", Domain: ".example.com", Path: "/", Session: true}},
+		Origins: []chrome.OriginStorage{
+			{Origin: "https://www.example.com", LocalStorage: map[string]string{"token": "t1"}},
+			{Origin: "https://other.example.com", LocalStorage: map[string]string{"x": "1"}},
+		},
+	}
+	err := tag.ImportSession(profile)
+	var ierr *chrome.SessionImportError
+	if !errors.As(err, &ierr) {
+		t.Fatalf("期望*SessionImportError，实际%v", err)
+	}
+	if len(ierr.Origins) != 1 || ierr.Origins["https://www.example.com"] == nil {
+		t.Errorf("失败的源错误：%v", ierr.Origins)
+	}
+	//失败的源不影响其余数据导入
+	if len(fb.called("Network.setCookie")) != 1 || len(fb.called("Page.addScriptToEvaluateOnNewDocument")) != 1 {
+		t.Errorf("其余数据未导入")
+	}
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"b/chrome"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//生成单色PNG图片
+func solidPng(w, h int, c color.Color) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+//PNG块
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+//按块拆分PNG文件并校验CRC
+func readChunks(t *testing.T, data []byte) []pngChunk {
+	if !bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")) {
+		t.Fatal("PNG文件签名错误")
+	}
+	data = data[8:]
+	chunks := make([]pngChunk, 0)
+	for len(data) > 0 {
+		if len(data) < 12 {
+			t.Fatalf("块不完整，剩余%d字节", len(data))
+		}
+		n := int(binary.BigEndian.Uint32(data))
+		if len(data) < 12+n {
+			t.Fatalf("块长度%d超出文件", n)
+		}
+		typ, body := string(data[4:8]), data[8:8+n]
+		if crc32.ChecksumIEEE(data[4:8+n]) != binary.BigEndian.Uint32(data[8+n:]) {
+			t.Fatalf("%s块CRC错误", typ)
+		}
+		chunks = append(chunks, pngChunk{typ: typ, data: body})
+		data = data[12+n:]
+	}
+	return chunks
+}
+
+func TestScreencastApng(t *testing.T) {
+	tag, fb := newFakeTag(t)
+	path := filepath.Join(t.TempDir(), "run.png")
+	if err := tag.StartScreencast(chrome.ScreencastOptions{File: path}); err != nil {
+		t.Fatal(err)
+	}
+	frames := []struct {
+		img       []byte
+		timestamp float64
+	}{
+		{solidPng(4, 3, color.NRGBA{255, 0, 0, 255}), 10.0},
+		{solidPng(4, 3, color.NRGBA{0, 255, 0, 255}), 10.2},
+		{solidPng(6, 5, color.NRGBA{0, 0, 255, 255}), 10.5}, //尺寸不同的帧按画布裁剪
+		{solidPng(4, 3, color.NRGBA{0, 0, 0, 255}), 11.25},
+	}
+	for i, f := range frames {
+		fb.emit("Page.screencastFrame", map[string]interface{}{
+			"data":      base64.StdEncoding.EncodeToString(f.img),
+			"metadata":  map[string]interface{}{"timestamp": f.timestamp},
+			"sessionId": i + 1,
+		})
+	}
+	if !fb.wait("Page.screencastFrameAck", len(frames), 3*time.Second) {
+		t.Fatal("等待确认帧超时")
+	}
+	if err := tag.StopScreencast(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := readChunks(t, data)
+	types := make([]string, 0)
+	for _, c := range chunks {
+		types = append(types, c.typ)
+	}
+	want := []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"}
+	if !reflect.DeepEqual(types, want) {
+		t.Fatalf("块顺序期望%v，实际%v", want, types)
+	}
+	if w, h := binary.BigEndian.Uint32(chunks[0].data), binary.BigEndian.Uint32(chunks[0].data[4:]); w != 4 || h != 3 {
+		t.Errorf("画布尺寸期望4x3，实际%dx%d", w, h)
+	}
+	//acTL的帧数在结束时回填，循环次数为0
+	if n, plays := binary.BigEndian.Uint32(chunks[1].data), binary.BigEndian.Uint32(chunks[1].data[4:]); n != uint32(len(frames)) || plays != 0 {
+		t.Errorf("acTL期望%d帧无限循环，实际%d帧循环%d次", len(frames), n, plays)
+	}
+	//fcTL和fdAT共用从0开始连续递增的序号
+	seqs := make([]uint32, 0)
+	delays := make([]uint16, 0)
+	for _, c := range chunks {
+		switch c.typ {
+		case "fcTL":
+			seqs = append(seqs, binary.BigEndian.Uint32(c.data))
+			if w, h := binary.BigEndian.Uint32(c.data[4:]), binary.BigEndian.Uint32(c.data[8:]); w != 4 || h != 3 {
+				t.Errorf("fcTL尺寸期望4x3，实际%dx%d", w, h)
+			}
+			if den := binary.BigEndian.Uint16(c.data[22:]); den != 1000 {
+				t.Errorf("fcTL延迟分母期望1000，实际%d", den)
+			}
+			delays = append(delays, binary.BigEndian.Uint16(c.data[20:]))
+		case "fdAT":
+			seqs = append(seqs, binary.BigEndian.Uint32(c.data))
+		}
+	}
+	if !reflect.DeepEqual(seqs, []uint32{0, 1, 2, 3, 4, 5, 6}) {
+		t.Errorf("序号期望0~6连续递增，实际%v", seqs)
+	}
+	//每帧显示到下一帧的截取时间，最后一帧使用默认时间
+	if !reflect.DeepEqual(delays, []uint16{200, 300, 750, 100}) {
+		t.Errorf("显示时间期望[200 300 750 100]，实际%v", delays)
+	}
+
+	//不认识APNG的解码器按普通PNG显示首帧
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(1, 1).RGBA(); r>>8 != 255 || g != 0 || b != 0 {
+		t.Errorf("首帧颜色错误：%v", img.At(1, 1))
+	}
+}
+
+func TestScreencastApngEmpty(t *testing.T) {
+	tag, _ := newFakeTag(t)
+	path := filepath.Join(t.TempDir(), "empty.apng")
+	if err := tag.StartScreencast(chrome.ScreencastOptions{File: path}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tag.StopScreencast(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("没有帧时不应保留文件：%v", err)
+	}
+}
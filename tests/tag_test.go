@@ -0,0 +1,22 @@
+package tests
+
+import (
+	"testing"
+)
+
+//关闭连接后立即重新连接，应建立新连接而不是沿用已关闭的会话
+func TestTagReconnectAfterClose(t *testing.T) {
+	tag, fb := newFakeTag(t)
+	for i := 1; i <= 20; i++ {
+		tag.Close(false)
+		if ok, err := tag.Connect(); !ok {
+			t.Fatalf("第%d次重新连接失败：%v", i, err)
+		}
+		if _, err := tag.Call("Runtime.evaluate", map[string]interface{}{"expression": "1"}); err != nil {
+			t.Fatalf("第%d次重新连接后调用失败：%v", i, err)
+		}
+		if n := len(fb.called("Page.enable")); n != i {
+			t.Fatalf("重新连接%d次，开启事件%d次", i, n)
+		}
+	}
+}
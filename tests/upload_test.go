@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"b/chrome"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//拦截文件选择对话框的同时开关拦截，开启后回调选择的文件应设置到对应元素；需配合-race运行检查数据竞争
+func TestHookFileChooserToggle(t *testing.T) {
+	tag, fb := newFakeTag(t)
+	file := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(file, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	handler := func(tag *chrome.Tag, chooser *chrome.FileChooser) {
+		if chooser.BackendNodeId == 7 {
+			chooser.SetFiles(file)
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			if err := tag.HookFileChooserEn(handler); err != nil {
+				t.Error(err)
+				return
+			}
+			if err := tag.HookFileChooserDis(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		fb.emit("Page.fileChooserOpened", map[string]interface{}{"mode": "selectSingle", "backendNodeId": 1})
+	}
+	<-done
+	if err := tag.HookFileChooserEn(handler); err != nil {
+		t.Fatal(err)
+	}
+	fb.emit("Page.fileChooserOpened", map[string]interface{}{"mode": "selectSingle", "backendNodeId": 7})
+	if !fb.wait("DOM.setFileInputFiles", 1, 5*time.Second) {
+		t.Fatal("开启拦截后回调未触发")
+	}
+	call := fb.called("DOM.setFileInputFiles")[0]
+	files, _ := call.Params["files"].([]interface{})
+	if call.Params["backendNodeId"] != float64(7) || len(files) != 1 || files[0] != file {
+		t.Fatalf("设置文件参数错误：%v", call.Params)
+	}
+}
@@ -1,3 +1,6 @@
+//go:build windows
+// +build windows
+
 //程序操作
 package windbig
 
@@ -1,3 +1,6 @@
+//go:build windows
+// +build windows
+
 //系统操作
 package windbig
 